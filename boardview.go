@@ -0,0 +1,158 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"example/tesourim/sim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// whiteImage é a textura usada para preencher polígonos com DrawTriangles
+var whiteImage = func() *ebiten.Image {
+	img := ebiten.NewImage(3, 3)
+	img.Fill(color.White)
+	return img.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+}()
+
+// boardView posiciona um sim.Board na tela: converte o plano de
+// sim.Board.Center em pixels e desenha células quadradas ou hexagonais
+type boardView struct {
+	board            sim.Board
+	nodeSize         float64 // Largura de uma célula, em pixels
+	originX, originY float64 // Pixel do centro da célula de cima à esquerda
+}
+
+// newBoardView encaixa o tabuleiro b no centro da área de gridWidth×gridHeight
+// que começa em (frameX, frameY)
+func newBoardView(b sim.Board, frameX, frameY int) boardView {
+	return fitBoardView(b, frameX, frameY, gridWidth, gridHeight)
+}
+
+// fitBoardView encaixa o tabuleiro b no centro da área de frameW×frameH que
+// começa em (frameX, frameY)
+func fitBoardView(b sim.Board, frameX, frameY, frameW, frameH int) boardView {
+	extentW, extentH := b.Extent()
+	w, h := float64(frameW), float64(frameH)
+	v := boardView{board: b, nodeSize: math.Floor(math.Min(w/extentW, h/extentH))}
+	left := float64(frameX) + (w-extentW*v.nodeSize)/2
+	top := float64(frameY) + (h-extentH*v.nodeSize)/2
+	v.originX = left + v.nodeSize/2
+	v.originY = top + v.halfHeight()
+	return v
+}
+
+// halfHeight é a distância do centro de uma célula até o topo dela
+func (v boardView) halfHeight() float64 {
+	if v.board.Hex {
+		return v.nodeSize / math.Sqrt(3)
+	}
+	return v.nodeSize / 2
+}
+
+// toScreen converte um ponto do plano de sim.Board.Center em pixels
+func (v boardView) toScreen(fx, fy float64) (float64, float64) {
+	return v.originX + fx*v.nodeSize, v.originY + fy*v.nodeSize
+}
+
+// cellCenter devolve o pixel do centro da célula (x, y)
+func (v boardView) cellCenter(x, y int) (float64, float64) {
+	return v.toScreen(v.board.Center(x, y))
+}
+
+// nodeCenter devolve o pixel do centro do nó node
+func (v boardView) nodeCenter(node int) (float64, float64) {
+	return v.cellCenter(node%v.board.Width, node/v.board.Width)
+}
+
+// cellAt devolve a célula sob o pixel (px, py)
+func (v boardView) cellAt(px, py int) (int, int) {
+	return v.board.CellAt((float64(px)-v.originX)/v.nodeSize, (float64(py)-v.originY)/v.nodeSize)
+}
+
+// drawFlag desenha uma bandeira sobre o nó node
+func (v boardView) drawFlag(screen *ebiten.Image, node int) {
+	cx, cy := v.nodeCenter(node)
+	size := v.nodeSize / 3
+	poleX, top, bottom := float32(cx-size/2), float32(cy-size), float32(cy+size)
+	vector.StrokeLine(screen, poleX, top, poleX, bottom, 2, color.Black, true)
+
+	var path vector.Path
+	path.MoveTo(poleX, top)
+	path.LineTo(poleX+float32(size*1.2), top+float32(size/2))
+	path.LineTo(poleX, top+float32(size))
+	path.Close()
+	fillPath(screen, &path, color.RGBA{230, 30, 30, 255})
+}
+
+// drawCell preenche a célula (x, y) com clr e desenha o contorno
+func (v boardView) drawCell(screen *ebiten.Image, x, y int, clr color.Color) {
+	cx, cy := v.cellCenter(x, y)
+	if !v.board.Hex {
+		left, top, size := cx-v.nodeSize/2, cy-v.nodeSize/2, v.nodeSize
+		ebitenutil.DrawRect(screen, left, top, size, size, clr)
+		vector.StrokeRect(screen, float32(left), float32(top), float32(size), float32(size), 1, color.Black, false)
+		return
+	}
+	drawHex(screen, cx, cy, v.halfHeight(), clr)
+}
+
+// drawWall desenha a parede entre os nós a e b: um traço grosso sobre a borda
+// que as duas células dividem
+func (v boardView) drawWall(screen *ebiten.Image, a, b int) {
+	x1, y1 := v.nodeCenter(a)
+	x2, y2 := v.nodeCenter(b)
+	mx, my := (x1+x2)/2, (y1+y2)/2
+	dx, dy := x2-x1, y2-y1
+	dist := math.Hypot(dx, dy)
+	if dist == 0 {
+		return
+	}
+	// Meia borda: metade do lado do quadrado ou do hexágono
+	half := v.nodeSize / 2
+	if v.board.Hex {
+		half = v.nodeSize / (2 * math.Sqrt(3))
+	}
+	px, py := -dy/dist*half, dx/dist*half
+	vector.StrokeLine(screen, float32(mx-px), float32(my-py), float32(mx+px), float32(my+py), 5, color.RGBA{90, 50, 20, 255}, true)
+}
+
+// drawHex desenha um hexágono com a ponta para cima, centro (cx, cy) e raio r
+func drawHex(screen *ebiten.Image, cx, cy, r float64, clr color.Color) {
+	var corners [6][2]float32
+	var path vector.Path
+	for i := range corners {
+		angle := math.Pi/6 + float64(i)*math.Pi/3
+		corners[i] = [2]float32{float32(cx + r*math.Cos(angle)), float32(cy + r*math.Sin(angle))}
+		if i == 0 {
+			path.MoveTo(corners[i][0], corners[i][1])
+		} else {
+			path.LineTo(corners[i][0], corners[i][1])
+		}
+	}
+	path.Close()
+	fillPath(screen, &path, clr)
+
+	for i := range corners {
+		a, b := corners[i], corners[(i+1)%len(corners)]
+		vector.StrokeLine(screen, a[0], a[1], b[0], b[1], 1, color.Black, true)
+	}
+}
+
+// fillPath preenche o polígono path com clr
+func fillPath(screen *ebiten.Image, path *vector.Path, clr color.Color) {
+	vs, is := path.AppendVerticesAndIndicesForFilling(nil, nil)
+	cr, cg, cb, ca := clr.RGBA()
+	for i := range vs {
+		vs[i].SrcX, vs[i].SrcY = 1, 1
+		vs[i].ColorR = float32(cr) / 0xffff
+		vs[i].ColorG = float32(cg) / 0xffff
+		vs[i].ColorB = float32(cb) / 0xffff
+		vs[i].ColorA = float32(ca) / 0xffff
+	}
+	screen.DrawTriangles(vs, is, whiteImage, &ebiten.DrawTrianglesOptions{AntiAlias: true})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// clipboardTimeout é quanto a leitura da área de transferência pode demorar
+// antes de ser abandonada
+const clipboardTimeout = 2 * time.Second
+
+// clipboardResult é o texto lido da área de transferência, ou o erro
+type clipboardResult struct {
+	text string
+	err  error
+}
+
+// readClipboard lê a área de transferência fora do loop do jogo e entrega o
+// resultado no canal devolvido. O Ebiten não lê a área de transferência,
+// então o texto vem do comando de cada sistema, que pode demorar a abrir ou
+// nunca responder; depois de clipboardTimeout o comando é morto.
+func readClipboard() <-chan clipboardResult {
+	result := make(chan clipboardResult, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), clipboardTimeout)
+		defer cancel()
+		var cmd *exec.Cmd
+		switch {
+		case runtime.GOOS == "windows":
+			cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", "Get-Clipboard")
+		case runtime.GOOS == "darwin":
+			cmd = exec.CommandContext(ctx, "pbpaste")
+		case os.Getenv("WAYLAND_DISPLAY") != "":
+			cmd = exec.CommandContext(ctx, "wl-paste", "--no-newline")
+		default:
+			cmd = exec.CommandContext(ctx, "xclip", "-selection", "clipboard", "-o")
+		}
+		out, err := cmd.Output()
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		result <- clipboardResult{string(out), err}
+	}()
+	return result
+}
@@ -0,0 +1,518 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"example/tesourim/level"
+	"example/tesourim/save"
+	"example/tesourim/sim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/font/basicfont"
+)
+
+// editorTool é uma ferramenta de pintura do editor: a letra que ela põe nas
+// linhas do level.File, a entrada do jogador ou a rota de um inimigo do
+// tabuleiro
+type editorTool struct {
+	name   string
+	cell   rune
+	start  bool // Marca a coluna de entrada em vez de pintar a célula
+	walker bool // Acrescenta a célula à rota de um inimigo do tabuleiro
+}
+
+// editorTools são as ferramentas do editor, as dez primeiras nas teclas 1 a 0
+var editorTools = func() []editorTool {
+	tools := []editorTool{{name: "Seguro", cell: level.Safe}}
+	for t := sim.TrapType(0); t < sim.TrapTypes; t++ {
+		tools = append(tools, editorTool{name: t.Info().Name, cell: level.TrapLetter(t)})
+	}
+	return append(tools,
+		editorTool{name: "Tesouro", cell: level.Treasure},
+		editorTool{name: "Vazio", cell: level.Void},
+		editorTool{name: "Entrada", start: true},
+		editorTool{name: "Rota de guarda", walker: true},
+	)
+}()
+
+// editorSlots é o número de arquivos em que o editor salva
+const editorSlots = 9
+
+// EditorScene é o editor de níveis: pinta as células com o mouse, muda o
+// tamanho e os inimigos pelo teclado, confere a cada mudança se o tesouro é
+// alcançável e testa o nível na hora
+type EditorScene struct {
+	game    *Game
+	file    level.File // Nível sendo editado
+	tool    int        // Ferramenta em editorTools
+	slot    int        // Arquivo de save, de 1 a editorSlots
+	problem error      // Por que o nível não pode ser jogado (nil = pode)
+	status  string     // Resultado do último save ou load
+	view    boardView  // Posição do tabuleiro no último Draw, para o mouse
+	route   bool       // Se a próxima célula da rota começa um novo inimigo do tabuleiro
+}
+
+func newEditorScene(game *Game) *EditorScene {
+	e := &EditorScene{game: game, slot: 1, tool: 1}
+	e.file = level.File{Title: "Nível do editor", Difficulty: 1, Enemies: new(int)}
+	e.resize(6, 6)
+	e.setCell(5, 5, level.Treasure)
+	e.check()
+	return e
+}
+
+// size devolve a largura e a altura do nível
+func (e *EditorScene) size() (int, int) {
+	if len(e.file.Rows) == 0 {
+		return 0, 0
+	}
+	return len([]rune(e.file.Rows[0])), len(e.file.Rows)
+}
+
+// cell devolve a letra da célula (x, y), com y = 0 na linha de baixo
+func (e *EditorScene) cell(x, y int) rune {
+	_, height := e.size()
+	return []rune(e.file.Rows[height-1-y])[x]
+}
+
+// setCell troca a letra da célula (x, y). Só pode haver um tesouro, então
+// pintar um novo apaga o antigo.
+func (e *EditorScene) setCell(x, y int, c rune) {
+	width, height := e.size()
+	if c == level.Treasure {
+		for yy := 0; yy < height; yy++ {
+			for xx := 0; xx < width; xx++ {
+				if e.cell(xx, yy) == level.Treasure {
+					e.setCell(xx, yy, level.Safe)
+				}
+			}
+		}
+	}
+	row := []rune(e.file.Rows[height-1-y])
+	row[x] = c
+	e.file.Rows[height-1-y] = string(row)
+	// Paredes junto de células vazias deixariam de existir
+	if c == level.Void {
+		node := y*width + x
+		e.file.Walls = slices.DeleteFunc(e.file.Walls, func(w [2]int) bool { return w[0] == node || w[1] == node })
+	}
+}
+
+// resize muda o tamanho do nível mantendo a linha de baixo, que é a da
+// entrada, e a coluna da esquerda
+func (e *EditorScene) resize(width, height int) {
+	width = min(max(width, 2), sim.MaxGridSize)
+	height = min(max(height, 2), sim.MaxGridSize)
+	oldWidth, oldHeight := e.size()
+	rows := make([]string, height)
+	for i := range rows {
+		row := make([]rune, width)
+		for x := range row {
+			row[x] = level.Safe
+			if y := height - 1 - i; x < oldWidth && y < oldHeight {
+				row[x] = e.cell(x, y)
+			}
+		}
+		rows[i] = string(row)
+	}
+	e.file.Rows = rows
+	e.file.Walls = nil // Os nós mudam de número com a largura
+	e.file.Walkers = nil
+	e.file.Start = min(e.file.Start, width-1)
+}
+
+// check confere se o nível pode ser jogado; por baixo, level.File.Level usa
+// utils.CanReach
+func (e *EditorScene) check() {
+	_, e.problem = e.file.Level()
+}
+
+// path devolve o arquivo do slot atual
+func (e *EditorScene) path() (string, error) {
+	dir, err := save.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "levels", fmt.Sprintf("nivel-%d.json", e.slot)), nil
+}
+
+// save grava o nível no slot atual, mesmo que ainda não possa ser jogado
+func (e *EditorScene) save() {
+	path, err := e.path()
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+	var data []byte
+	if err == nil {
+		data, err = e.file.Encode()
+	}
+	if err == nil {
+		err = os.WriteFile(path, data, 0o644)
+	}
+	if err != nil {
+		log.Println(err)
+		e.status = "Erro ao salvar: " + err.Error()
+		return
+	}
+	e.status = "Salvo em " + path
+}
+
+// load lê o nível do slot atual
+func (e *EditorScene) load() {
+	path, err := e.path()
+	if err != nil {
+		e.status = "Erro ao abrir: " + err.Error()
+		return
+	}
+	f, err := level.ReadFile(os.DirFS(filepath.Dir(path)), filepath.Base(path))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		e.status = fmt.Sprintf("Nenhum nível salvo no arquivo %d", e.slot)
+		return
+	case err != nil:
+		e.status = "Erro ao abrir: " + err.Error()
+		return
+	}
+	// O editor precisa de um retângulo; o resto ele deixa consertar
+	rectangle := len(f.Rows) > 0
+	for _, row := range f.Rows {
+		rectangle = rectangle && row != "" && len([]rune(row)) == len([]rune(f.Rows[0]))
+	}
+	if !rectangle {
+		e.status = "Erro ao abrir: linhas de tamanhos diferentes"
+		return
+	}
+	if f.Enemies == nil {
+		f.Enemies = new(int)
+	}
+	e.file = f
+	width, height := e.size()
+	e.resize(width, height)
+	e.status = "Aberto " + path
+	// Paredes e rotas usam os nós da largura do arquivo: só valem se o
+	// tamanho coube no editor
+	if newWidth, newHeight := e.size(); newWidth == width && newHeight == height {
+		e.file.Walls, e.file.Walkers = f.Walls, f.Walkers
+	} else if len(f.Walls) > 0 || len(f.Walkers) > 0 {
+		e.status += fmt.Sprintf(" (ajustado para %d×%d, sem paredes e guardas)", newWidth, newHeight)
+	} else {
+		e.status += fmt.Sprintf(" (ajustado para %d×%d)", newWidth, newHeight)
+	}
+	e.check()
+}
+
+// addWaypoint acrescenta a célula (x, y) à rota do último inimigo do
+// tabuleiro, ou começa um novo guarda depois da tecla G
+func (e *EditorScene) addWaypoint(x, y int) {
+	width, _ := e.size()
+	if e.route || len(e.file.Walkers) == 0 {
+		e.file.Walkers = append(e.file.Walkers, level.Walker{Kind: level.WalkerName(sim.Guard)})
+		e.route = false
+	}
+	last := &e.file.Walkers[len(e.file.Walkers)-1]
+	last.Route = append(last.Route, y*width+x)
+}
+
+// enemies monta só a parte dos inimigos do nível: quantos são, o lado e o
+// comportamento de cada um, sobre a geometria do tabuleiro
+func (e *EditorScene) enemies() sim.Level {
+	width, height := e.size()
+	l := sim.Level{Board: sim.Board{Width: width, Height: height, Hex: e.file.Hex}, Enemies: *e.file.Enemies}
+	for _, name := range e.file.Behaviors {
+		k, _ := level.BehaviorKind(name)
+		l.Behaviors = append(l.Behaviors, k)
+	}
+	for _, name := range e.file.Sides {
+		side, _ := level.Side(name)
+		l.Sides = append(l.Sides, side)
+	}
+	return l
+}
+
+// enemyCenter devolve o pixel do centro do inimigo i, na posição em que ele
+// começa o nível
+func (e *EditorScene) enemyCenter(i int) (float64, float64) {
+	l := e.enemies()
+	return e.view.toScreen(l.EnemyPoint(l.EnemyStart(i)))
+}
+
+// cycleEnemy passa o inimigo i para o próximo comportamento ou, com side,
+// para o próximo lado. As listas ganham um nome por inimigo, para só ele
+// mudar.
+func (e *EditorScene) cycleEnemy(i int, side bool) {
+	l := e.enemies()
+	behaviors := make([]string, l.Enemies)
+	sides := make([]string, l.Enemies)
+	for j := range behaviors {
+		behaviors[j] = level.BehaviorName(l.Behavior(j))
+		sides[j] = level.SideName(l.Side(j))
+	}
+	if side {
+		sides[i] = level.SideName((l.Side(i) + 1) % sim.Sides)
+	} else {
+		behaviors[i] = level.BehaviorName((l.Behavior(i) + 1) % sim.BehaviorKinds)
+	}
+	e.file.Behaviors, e.file.Sides = behaviors, sides
+}
+
+// playtest joga o nível numa partida à parte, que não salva progresso
+func (e *EditorScene) playtest() {
+	l, err := e.file.Level()
+	if err != nil {
+		return
+	}
+	start := sim.LevelProgress(l)
+	play := newPlayScene(e.game, sim.ResumeWorld(e.game.newSeed(), start, nil))
+	play.playtest = true
+	e.game.record(play, &start)
+	e.game.Push(play)
+}
+
+func (e *EditorScene) Update() error {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		e.game.Pop()
+		return nil
+	}
+	ctrl := ebiten.IsKeyPressed(ebiten.KeyControl)
+	changed := false
+
+	// Ferramentas: teclas 1 a 0 ou clique na lista
+	for i, key := range []ebiten.Key{ebiten.Key1, ebiten.Key2, ebiten.Key3, ebiten.Key4, ebiten.Key5, ebiten.Key6, ebiten.Key7, ebiten.Key8, ebiten.Key9, ebiten.Key0} {
+		if i < len(editorTools) && inpututil.IsKeyJustPressed(key) {
+			e.tool = i
+		}
+	}
+	mx, my := ebiten.CursorPosition()
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && mx < editorPanelW && my > editorToolsTop-editorLineH {
+		if i := (my - editorToolsTop + editorLineH - 1) / editorLineH; i < len(editorTools) {
+			e.tool = i
+		}
+	}
+
+	// Clicar num inimigo troca o comportamento dele; o botão direito, o lado
+	left := inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft)
+	if right := inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight); (left || right) && e.view.nodeSize > 0 {
+		for i := 0; i < *e.file.Enemies; i++ {
+			ex, ey := e.enemyCenter(i)
+			if math.Abs(float64(mx)-ex) <= 8 && math.Abs(float64(my)-ey) <= 8 {
+				e.cycleEnemy(i, right)
+				changed = true
+			}
+		}
+	}
+
+	// Pintura: esquerdo pinta com a ferramenta, direito apaga
+	width, height := e.size()
+	if e.view.nodeSize > 0 {
+		x, y := e.view.cellAt(mx, my)
+		inside := x >= 0 && x < width && y >= 0 && y < height
+		tool := editorTools[e.tool]
+		switch {
+		case tool.walker:
+			if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && inside {
+				e.addWaypoint(x, y)
+				changed = true
+			}
+		case ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) && tool.start && x >= 0 && x < width && y >= -1 && y < height:
+			changed = e.file.Start != x
+			e.file.Start = x
+		case ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) && inside && !tool.start && e.cell(x, y) != tool.cell:
+			e.setCell(x, y, tool.cell)
+			changed = true
+		case ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight) && inside && e.cell(x, y) != level.Safe:
+			e.setCell(x, y, level.Safe)
+			changed = true
+		}
+	}
+
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyLeft):
+		e.resize(width-1, height)
+		changed = true
+	case inpututil.IsKeyJustPressed(ebiten.KeyRight):
+		e.resize(width+1, height)
+		changed = true
+	case inpututil.IsKeyJustPressed(ebiten.KeyDown):
+		e.resize(width, height-1)
+		changed = true
+	case inpututil.IsKeyJustPressed(ebiten.KeyUp):
+		e.resize(width, height+1)
+		changed = true
+	case inpututil.IsKeyJustPressed(ebiten.KeyD):
+		e.file.Difficulty = e.file.Difficulty%3 + 1
+		changed = true
+	case inpututil.IsKeyJustPressed(ebiten.KeyG):
+		e.route = true
+	case inpututil.IsKeyJustPressed(ebiten.KeyK) && len(e.file.Walkers) > 0:
+		last := &e.file.Walkers[len(e.file.Walkers)-1]
+		kind, _ := level.WalkerKind(last.Kind)
+		last.Kind = level.WalkerName((kind + 1) % sim.WalkerKinds)
+		changed = true
+	case inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(e.file.Walkers) > 0:
+		last := &e.file.Walkers[len(e.file.Walkers)-1]
+		if last.Route = last.Route[:len(last.Route)-1]; len(last.Route) == 0 {
+			e.file.Walkers = e.file.Walkers[:len(e.file.Walkers)-1]
+		}
+		changed = true
+	case inpututil.IsKeyJustPressed(ebiten.KeyN):
+		e.file.Dark = !e.file.Dark
+		changed = true
+	case inpututil.IsKeyJustPressed(ebiten.KeyEqual) || inpututil.IsKeyJustPressed(ebiten.KeyKPAdd):
+		*e.file.Enemies = min(*e.file.Enemies+1, 6)
+		changed = true
+	case inpututil.IsKeyJustPressed(ebiten.KeyMinus) || inpututil.IsKeyJustPressed(ebiten.KeyKPSubtract):
+		*e.file.Enemies = max(*e.file.Enemies-1, 0)
+		if len(e.file.Behaviors) > *e.file.Enemies {
+			e.file.Behaviors = e.file.Behaviors[:*e.file.Enemies]
+		}
+		if len(e.file.Sides) > *e.file.Enemies {
+			e.file.Sides = e.file.Sides[:*e.file.Enemies]
+		}
+		if len(e.file.Weapons) > *e.file.Enemies {
+			e.file.Weapons = e.file.Weapons[:*e.file.Enemies]
+		}
+		changed = true
+	case inpututil.IsKeyJustPressed(ebiten.KeyPageUp):
+		e.slot = e.slot%editorSlots + 1
+	case inpututil.IsKeyJustPressed(ebiten.KeyPageDown):
+		e.slot = (e.slot+editorSlots-2)%editorSlots + 1
+	case ctrl && inpututil.IsKeyJustPressed(ebiten.KeyS):
+		e.save()
+	case ctrl && inpututil.IsKeyJustPressed(ebiten.KeyO):
+		e.load()
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		e.playtest()
+	}
+	if changed {
+		e.check()
+	}
+	return nil
+}
+
+// Medidas do painel do editor, em pixels
+const (
+	editorPanelW   = 200
+	editorStatusH  = 40 // Linhas de estado embaixo do tabuleiro
+	editorToolsTop = 60
+	editorLineH    = 18
+)
+
+// editorColor é a cor de cada letra de célula no editor
+func editorColor(c rune) color.Color {
+	if t, ok := level.CellTrap(c); ok {
+		return t.Info().Color
+	}
+	if c == level.Treasure {
+		return color.RGBA{0, 255, 0, 255}
+	}
+	return color.RGBA{200, 200, 200, 255}
+}
+
+func (e *EditorScene) Draw(screen *ebiten.Image) {
+	screen.Fill(color.Black)
+	sw, sh := screen.Bounds().Dx(), screen.Bounds().Dy()
+	face := basicfont.Face7x13
+
+	// Ferramentas
+	text.Draw(screen, "Editor de níveis", mplusBoldFont, 20, 30, color.RGBA{255, 215, 0, 255})
+	for i, tool := range editorTools {
+		y := editorToolsTop + i*editorLineH
+		clr := color.Color(color.White)
+		if i == e.tool {
+			clr = color.RGBA{255, 215, 0, 255}
+			text.Draw(screen, ">", face, 12, y, clr)
+		}
+		if !tool.start && tool.cell != level.Void {
+			ebitenutil.DrawRect(screen, 24, float64(y-10), 10, 10, editorColor(tool.cell))
+		}
+		label := tool.name
+		if i < 10 {
+			label = fmt.Sprintf("%d %s", (i+1)%10, tool.name)
+		}
+		text.Draw(screen, label, face, 40, y, clr)
+	}
+
+	// Tabuleiro
+	width, height := e.size()
+	geometry := sim.Board{Width: width, Height: height, Hex: e.file.Hex}
+	// Na janela, a tela é do tamanho do tabuleiro: ele fica à direita do
+	// painel e encolhe para caber, com as linhas de estado embaixo
+	frameX, frameY := max((sw-gridWidth)/2, editorPanelW), max((sh-gridHeight)/2, 0)
+	frameW, frameH := min(gridWidth, sw-frameX), min(gridHeight, sh-frameY-editorStatusH)
+	e.view = fitBoardView(geometry, frameX, frameY, frameW, frameH)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if c := e.cell(x, y); c != level.Void {
+				e.view.drawCell(screen, x, y, editorColor(c))
+			}
+		}
+	}
+	if b, err := e.file.Board(); err == nil {
+		for _, wall := range b.Walls {
+			e.view.drawWall(screen, wall[0], wall[1])
+		}
+	}
+	startX, startY := e.view.cellCenter(e.file.Start, -1)
+	ebitenutil.DrawCircle(screen, startX, startY, e.view.nodeSize/4, color.RGBA{0, 160, 255, 255})
+	enemies := *e.file.Enemies
+	for i := 0; i < enemies; i++ {
+		ex, ey := e.enemyCenter(i)
+		ebitenutil.DrawRect(screen, ex-8, ey-8, 16, 16, color.RGBA{255, 0, 0, 255})
+		name := e.enemies().Behavior(i).Name()
+		text.Draw(screen, name, face, int(ex)-len(name)*7/2, int(ey)+22, color.White)
+	}
+	for _, k := range e.file.Walkers {
+		kind, _ := level.WalkerKind(k.Kind)
+		clr := walkerColors[kind]
+		for i, node := range k.Route {
+			next := k.Route[(i+1)%len(k.Route)]
+			x1, y1 := e.view.cellCenter(node%width, node/width)
+			x2, y2 := e.view.cellCenter(next%width, next/width)
+			vector.StrokeLine(screen, float32(x1), float32(y1), float32(x2), float32(y2), 2, clr, true)
+		}
+		x, y := e.view.cellCenter(k.Route[0]%width, k.Route[0]/width)
+		ebitenutil.DrawCircle(screen, x, y, e.view.nodeSize/5, clr)
+	}
+
+	// Estado e ajuda
+	dark := "Não"
+	if e.file.Dark {
+		dark = "Sim"
+	}
+	info := []string{
+		fmt.Sprintf("Tamanho: %dx%d (setas)", width, height),
+		fmt.Sprintf("Dificuldade: %d (D)", e.file.Difficulty),
+		"Escuro: " + dark + " (N)",
+		fmt.Sprintf("Inimigos: %d (+/-)", enemies),
+		"Inimigo: clique muda comportamento",
+		"e botão direito muda o lado",
+		fmt.Sprintf("Guardas: %d (G novo, K tipo,", len(e.file.Walkers)),
+		"Backspace desfaz ponto)",
+		fmt.Sprintf("Arquivo: %d (PgUp/PgDn)", e.slot),
+		"Ctrl+S salvar | Ctrl+O abrir",
+		"ENTER testar | ESC voltar",
+		"Botão direito apaga",
+	}
+	top := editorToolsTop + (len(editorTools)+1)*editorLineH
+	for i, line := range info {
+		text.Draw(screen, line, face, 20, top+i*editorLineH, color.White)
+	}
+	check, clr := "Resolvível: ENTER para testar", color.RGBA{0, 200, 0, 255}
+	if e.problem != nil {
+		check, clr = e.problem.Error(), color.RGBA{255, 80, 80, 255}
+	}
+	text.Draw(screen, check, face, frameX, frameY+frameH+18, clr)
+	text.Draw(screen, e.status, face, frameX, frameY+frameH+34, color.White)
+}
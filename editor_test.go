@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"example/tesourim/level"
+	"example/tesourim/sim"
+)
+
+// TestEditorRoundTrip salva um nível pelo editor e o abre de novo: nada do
+// arquivo pode se perder no caminho
+func TestEditorRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", dir)
+
+	enemies := 2
+	e := newEditorScene(nil)
+	e.file = level.File{
+		Title:      "Ida e volta",
+		Difficulty: 2,
+		Rows:       []string{"..T", ".X.", "..S"},
+		Walls:      [][2]int{{0, 1}},
+		Dark:       true,
+		Start:      1,
+		Enemies:    &enemies,
+		Behaviors:  []string{"caçador"},
+		Sides:      []string{"esquerda", "cima"},
+		Walkers:    []level.Walker{{Kind: "batedor", Route: []int{0, 4, 8}}},
+	}
+	want, err := e.file.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.save()
+
+	e = newEditorScene(nil)
+	e.load()
+	got, err := e.file.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s\nnível aberto:\n%s\nqueria:\n%s", e.status, got, want)
+	}
+}
+
+// TestEditorLoadTooWide abre um nível mais largo que o editor aceita: o
+// tabuleiro é cortado e as paredes e rotas, numeradas pela largura antiga,
+// ficam de fora
+func TestEditorLoadTooWide(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", dir)
+
+	wide := strings.Repeat(".", sim.MaxGridSize+2)
+	e := newEditorScene(nil)
+	e.file.Rows = []string{"T" + wide[1:], wide, "S" + wide[1:]}
+	e.file.Walls = [][2]int{{0, 1}}
+	e.file.Walkers = []level.Walker{{Kind: "batedor", Route: []int{0, 1}}}
+	e.save()
+
+	e = newEditorScene(nil)
+	e.load()
+	if width, height := e.size(); width != sim.MaxGridSize || height != 3 {
+		t.Errorf("editor com %d×%d, queria %d×3", width, height, sim.MaxGridSize)
+	}
+	if e.file.Walls != nil || e.file.Walkers != nil {
+		t.Errorf("paredes %v e guardas %v continuaram depois do corte", e.file.Walls, e.file.Walkers)
+	}
+	if !strings.Contains(e.status, "sem paredes e guardas") {
+		t.Errorf("status %q não avisa do corte", e.status)
+	}
+}
+
+// TestSetCellVoid esvazia uma célula e confere que só as paredes dela somem
+func TestSetCellVoid(t *testing.T) {
+	e := newEditorScene(nil)
+	e.resize(3, 3)
+	e.file.Walls = [][2]int{{0, 1}, {1, 4}, {4, 5}, {7, 8}}
+	e.setCell(1, 1, level.Void)
+	if want := [][2]int{{0, 1}, {7, 8}}; !reflect.DeepEqual(e.file.Walls, want) {
+		t.Errorf("paredes %v, queria %v", e.file.Walls, want)
+	}
+}
@@ -0,0 +1,448 @@
+// Package level lê níveis feitos à mão e a campanha que os encadeia.
+//
+// Um nível é um arquivo JSON como:
+//
+//	{
+//	  "title": "Primeiros passos",
+//	  "difficulty": 1,
+//	  "rows": [
+//	    "XX.T",
+//	    "X..X",
+//	    "..XX",
+//	    ".XXX"
+//	  ],
+//	  "enemies": 0,
+//	  "time": 120,
+//	  "memorize": 20,
+//	  "lives": 2,
+//	  "rocks": 3
+//	}
+//
+// rows desenha o tabuleiro de cima para baixo, uma letra por célula: '.' é
+// uma célula segura, 'T' o tesouro, ' ' uma célula vazia e as armadilhas são
+// 'X' (buraco), 'S' (espinhos), 'P' (teletransporte), 'G' (cola), 'C' (piso
+// frágil) e 'A' (alarme). Os campos opcionais "hex", "movement" ("rei",
+// "torre", "bispo" ou "cavalo"), "walls" (pares de nós), "hide_walls",
+// "hints" e "dark" seguem os de sim.Board, "start" é a coluna onde o jogador
+// começa e "torches" quantas tochas ele tem para o modo escuro. "behaviors"
+// lista o comportamento de cada inimigo ("andarilho", "patrulheiro",
+// "caçador", "emboscador", "previsor" ou "covarde") e "sides" o lado do
+// tabuleiro de cada um ("cima", "baixo", "esquerda" ou "direita") e
+// "weapons" a arma de cada um ("reto", "triplo", "leque", "mirado" ou
+// "teleguiado"; sem ela, cada comportamento usa a sua); as listas se repetem
+// se houver mais inimigos que nomes. "walkers" são os inimigos que
+// andam dentro do tabuleiro, cada um com "kind" ("guarda", que desvia das
+// armadilhas, ou "batedor", que pisa nelas) e "route", os nós da patrulha.
+// Tempos são em segundos; limites ausentes ficam como os de sim.NewLevel.
+//
+// A campanha é um manifesto com os arquivos dos níveis, em ordem, relativos
+// à pasta do manifesto:
+//
+//	{"levels": ["01-primeiros-passos.json", "02-paredes.json"]}
+package level
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"example/tesourim/sim"
+	"example/tesourim/utils"
+)
+
+var (
+	ErrFormat     = errors.New("level: arquivo inválido")
+	ErrUnsolvable = errors.New("level: tesouro inalcançável")
+)
+
+// File é o conteúdo de um arquivo de nível
+type File struct {
+	Title      string   `json:"title"`
+	Difficulty int      `json:"difficulty"`
+	Rows       []string `json:"rows"`
+	Hex        bool     `json:"hex,omitempty"`
+	Movement   string   `json:"movement,omitempty"`
+	Walls      [][2]int `json:"walls,omitempty"`
+	HideWalls  bool     `json:"hide_walls,omitempty"`
+	Hints      bool     `json:"hints,omitempty"`
+	Dark       bool     `json:"dark,omitempty"`
+	Start      int      `json:"start,omitempty"`
+	Enemies    *int     `json:"enemies,omitempty"`
+	Behaviors  []string `json:"behaviors,omitempty"`
+	Sides      []string `json:"sides,omitempty"`
+	Weapons    []string `json:"weapons,omitempty"`
+	Walkers    []Walker `json:"walkers,omitempty"`
+	Time       *int     `json:"time,omitempty"`     // Tempo para achar o tesouro, em segundos
+	Memorize   *int     `json:"memorize,omitempty"` // Duração da memorização, em segundos
+	Lives      *int     `json:"lives,omitempty"`
+	Rocks      *int     `json:"rocks,omitempty"`
+	Torches    *int     `json:"torches,omitempty"`
+}
+
+// Walker é um inimigo do tabuleiro no arquivo de nível
+type Walker struct {
+	Kind  string `json:"kind"`
+	Route []int  `json:"route"`
+}
+
+// Manifest é o conteúdo do manifesto da campanha
+type Manifest struct {
+	Levels []string `json:"levels"`
+}
+
+// Letras de rows que não são armadilhas
+const (
+	Safe     = '.'
+	Treasure = 'T'
+	Void     = ' '
+)
+
+// trapLetters são as letras de cada tipo de armadilha em rows
+var trapLetters = map[rune]sim.TrapType{
+	'X': sim.Pit,
+	'S': sim.Spike,
+	'P': sim.Teleporter,
+	'G': sim.Sticky,
+	'C': sim.Crumbling,
+	'A': sim.Alarm,
+}
+
+// movementNames são os nomes aceitos em "movement"
+var movementNames = map[string]utils.Movement{
+	"":       utils.King,
+	"rei":    utils.King,
+	"torre":  utils.Orthogonal,
+	"bispo":  utils.Diagonal,
+	"cavalo": utils.Knight,
+}
+
+// behaviorNames são os nomes aceitos em "behaviors"
+var behaviorNames = func() map[string]sim.BehaviorKind {
+	names := make(map[string]sim.BehaviorKind)
+	for k := sim.BehaviorKind(0); k < sim.BehaviorKinds; k++ {
+		names[BehaviorName(k)] = k
+	}
+	return names
+}()
+
+// BehaviorName devolve o nome do comportamento k em "behaviors"
+func BehaviorName(k sim.BehaviorKind) string {
+	return strings.ToLower(k.Name())
+}
+
+// BehaviorKind devolve o comportamento de nome name em "behaviors"
+func BehaviorKind(name string) (sim.BehaviorKind, bool) {
+	k, ok := behaviorNames[name]
+	return k, ok
+}
+
+// sideNames são os nomes aceitos em "sides"
+var sideNames = func() map[string]sim.Side {
+	names := make(map[string]sim.Side)
+	for s := sim.Side(0); s < sim.Sides; s++ {
+		names[SideName(s)] = s
+	}
+	return names
+}()
+
+// SideName devolve o nome do lado s em "sides"
+func SideName(s sim.Side) string {
+	return strings.ToLower(s.Name())
+}
+
+// weaponNames são os nomes aceitos em "weapons"
+var weaponNames = func() map[string]sim.WeaponKind {
+	names := make(map[string]sim.WeaponKind)
+	for k := sim.WeaponKind(0); k < sim.WeaponKinds; k++ {
+		names[WeaponName(k)] = k
+	}
+	return names
+}()
+
+// WeaponName devolve o nome da arma k em "weapons"
+func WeaponName(k sim.WeaponKind) string {
+	return strings.ToLower(k.Name())
+}
+
+// WeaponKind devolve a arma de nome name em "weapons"
+func WeaponKind(name string) (sim.WeaponKind, bool) {
+	k, ok := weaponNames[name]
+	return k, ok
+}
+
+// walkerNames são os nomes aceitos em "kind" de "walkers"
+var walkerNames = func() map[string]sim.WalkerKind {
+	names := make(map[string]sim.WalkerKind)
+	for k := sim.WalkerKind(0); k < sim.WalkerKinds; k++ {
+		names[WalkerName(k)] = k
+	}
+	return names
+}()
+
+// WalkerName devolve o nome do tipo k em "kind" de "walkers"
+func WalkerName(k sim.WalkerKind) string {
+	return strings.ToLower(k.Name())
+}
+
+// WalkerKind devolve o tipo de nome name em "kind" de "walkers"
+func WalkerKind(name string) (sim.WalkerKind, bool) {
+	k, ok := walkerNames[name]
+	return k, ok
+}
+
+// Side devolve o lado de nome name em "sides"
+func Side(name string) (sim.Side, bool) {
+	s, ok := sideNames[name]
+	return s, ok
+}
+
+// TrapLetter devolve a letra do tipo de armadilha t em rows
+func TrapLetter(t sim.TrapType) rune {
+	for letter, tt := range trapLetters {
+		if tt == t {
+			return letter
+		}
+	}
+	return 'X'
+}
+
+// CellTrap diz se a letra c de rows é uma armadilha, e de que tipo
+func CellTrap(c rune) (sim.TrapType, bool) {
+	t, ok := trapLetters[c]
+	return t, ok
+}
+
+// Parse lê um nível em JSON e confere, com utils.CanReach, que o tesouro
+// pode ser alcançado
+func Parse(data []byte) (sim.Level, error) {
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return sim.Level{}, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	return f.Level()
+}
+
+// Level monta o nível descrito por f
+func (f File) Level() (sim.Level, error) {
+	b, err := f.Board()
+	if err != nil {
+		return sim.Level{}, err
+	}
+	if !b.Solvable() {
+		return sim.Level{}, ErrUnsolvable
+	}
+	if !b.Valid() {
+		return sim.Level{}, fmt.Errorf("%w: tabuleiro fora das regras do jogo", ErrFormat)
+	}
+	if f.Start < 0 || f.Start >= b.Width {
+		return sim.Level{}, fmt.Errorf("%w: entrada fora do tabuleiro", ErrFormat)
+	}
+
+	l := sim.NewLevel(b)
+	l.Title = f.Title
+	l.Start = f.Start
+	for _, name := range f.Behaviors {
+		k, ok := BehaviorKind(name)
+		if !ok {
+			return sim.Level{}, fmt.Errorf("%w: comportamento %q", ErrFormat, name)
+		}
+		l.Behaviors = append(l.Behaviors, k)
+	}
+	for _, name := range f.Sides {
+		s, ok := Side(name)
+		if !ok {
+			return sim.Level{}, fmt.Errorf("%w: lado %q", ErrFormat, name)
+		}
+		l.Sides = append(l.Sides, s)
+	}
+	for _, name := range f.Weapons {
+		k, ok := WeaponKind(name)
+		if !ok {
+			return sim.Level{}, fmt.Errorf("%w: arma %q", ErrFormat, name)
+		}
+		l.Weapons = append(l.Weapons, k)
+	}
+	for i, walker := range f.Walkers {
+		spec, err := walker.spec(b)
+		if err != nil {
+			return sim.Level{}, fmt.Errorf("%w: inimigo do tabuleiro %d: %v", ErrFormat, i+1, err)
+		}
+		l.Walkers = append(l.Walkers, spec)
+	}
+	limits := []struct {
+		value *int
+		field *int
+		scale int
+	}{
+		{f.Enemies, &l.Enemies, 1},
+		{f.Time, &l.GameTime, 60},
+		{f.Memorize, &l.MemorizeTime, 60},
+		{f.Lives, &l.Lives, 1},
+		{f.Rocks, &l.Rocks, 1},
+		{f.Torches, &l.Torches, 1},
+	}
+	for _, limit := range limits {
+		if limit.value == nil {
+			continue
+		}
+		if *limit.value < 0 {
+			return sim.Level{}, fmt.Errorf("%w: limite negativo", ErrFormat)
+		}
+		*limit.field = *limit.value * limit.scale
+	}
+	if l.GameTime == 0 || l.Lives == 0 {
+		return sim.Level{}, fmt.Errorf("%w: nível sem tempo ou sem vidas", ErrFormat)
+	}
+	return l, nil
+}
+
+// Board monta o tabuleiro de f, sem conferir se é resolvível
+func (f File) Board() (sim.Board, error) {
+	movement, ok := movementNames[f.Movement]
+	if !ok {
+		return sim.Board{}, fmt.Errorf("%w: movimento %q", ErrFormat, f.Movement)
+	}
+	b := sim.Board{
+		Height:     len(f.Rows),
+		Hex:        f.Hex,
+		Movement:   movement,
+		HideWalls:  f.HideWalls,
+		Hints:      f.Hints,
+		Dark:       f.Dark,
+		Difficulty: f.Difficulty,
+		Treasure:   -1,
+		Traps:      make(map[int]bool),
+	}
+	for i, row := range f.Rows {
+		cells := []rune(row)
+		if i == 0 {
+			b.Width = len(cells)
+		} else if len(cells) != b.Width {
+			return sim.Board{}, fmt.Errorf("%w: linha %d com tamanho diferente", ErrFormat, i+1)
+		}
+
+		y := b.Height - 1 - i // A primeira linha é a de cima
+		for x, c := range cells {
+			node := b.Node(x, y)
+			switch c {
+			case Safe:
+			case Treasure:
+				if b.Treasure >= 0 {
+					return sim.Board{}, fmt.Errorf("%w: mais de um tesouro", ErrFormat)
+				}
+				b.Treasure = node
+			case Void:
+				if b.Void == nil {
+					b.Void = make(map[int]bool)
+				}
+				b.Void[node] = true
+			default:
+				t, ok := trapLetters[c]
+				if !ok {
+					return sim.Board{}, fmt.Errorf("%w: célula %q", ErrFormat, c)
+				}
+				b.Traps[node] = true
+				if t != sim.Pit {
+					if b.TrapTypes == nil {
+						b.TrapTypes = make(map[int]sim.TrapType)
+					}
+					b.TrapTypes[node] = t
+				}
+			}
+		}
+	}
+	if b.Treasure < 0 {
+		return sim.Board{}, fmt.Errorf("%w: nível sem tesouro", ErrFormat)
+	}
+
+	slots := make(map[utils.Wall]bool)
+	for _, slot := range b.Shape().WallSlots() {
+		slots[slot] = true
+	}
+	for _, pair := range f.Walls {
+		wall := utils.NewWall(pair[0], pair[1])
+		if !slots[wall] {
+			return sim.Board{}, fmt.Errorf("%w: parede %v entre células que não são vizinhas", ErrFormat, pair)
+		}
+		b.Walls = append(b.Walls, wall)
+	}
+	return b, nil
+}
+
+// spec confere o inimigo k no tabuleiro b: a rota precisa passar só por
+// células do tabuleiro e, para o guarda, fora das armadilhas
+func (k Walker) spec(b sim.Board) (sim.WalkerSpec, error) {
+	kind, ok := WalkerKind(k.Kind)
+	if !ok {
+		return sim.WalkerSpec{}, fmt.Errorf("tipo %q", k.Kind)
+	}
+	if len(k.Route) == 0 {
+		return sim.WalkerSpec{}, errors.New("rota vazia")
+	}
+	for _, node := range k.Route {
+		if node < 0 || node >= b.Width*b.Height || !b.Inside(node%b.Width, node/b.Width) {
+			return sim.WalkerSpec{}, fmt.Errorf("nó %d fora do tabuleiro", node)
+		}
+		if kind == sim.Guard && b.Traps[node] {
+			return sim.WalkerSpec{}, fmt.Errorf("guarda com rota pelo nó %d, que é uma armadilha", node)
+		}
+	}
+	return sim.WalkerSpec{Kind: kind, Route: k.Route}, nil
+}
+
+// Load lê o nível do arquivo name em fsys
+func Load(fsys fs.FS, name string) (sim.Level, error) {
+	f, err := ReadFile(fsys, name)
+	if err != nil {
+		return sim.Level{}, err
+	}
+	l, err := f.Level()
+	if err != nil {
+		return sim.Level{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return l, nil
+}
+
+// ReadFile lê o arquivo de nível name em fsys sem montar o nível, para quem
+// quer editá-lo
+func ReadFile(fsys fs.FS, name string) (File, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return File{}, err
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return File{}, fmt.Errorf("%s: %w: %v", name, ErrFormat, err)
+	}
+	return f, nil
+}
+
+// Encode gera o JSON de f, no formato lido por Parse
+func (f File) Encode() ([]byte, error) {
+	return json.MarshalIndent(f, "", "  ")
+}
+
+// LoadCampaign lê o manifesto manifest em fsys e todos os níveis dele
+func LoadCampaign(fsys fs.FS, manifest string) ([]sim.Level, error) {
+	data, err := fs.ReadFile(fsys, manifest)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w: %v", manifest, ErrFormat, err)
+	}
+
+	levels := make([]sim.Level, 0, len(m.Levels))
+	for _, name := range m.Levels {
+		l, err := Load(fsys, path.Join(path.Dir(manifest), name))
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, l)
+	}
+	return levels, nil
+}
@@ -0,0 +1,183 @@
+package level
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"example/tesourim/sim"
+	"example/tesourim/utils"
+)
+
+// TestParse lê um nível com todas as letras e campos opcionais. O desenho
+// vai de cima para baixo, então a primeira linha é y = 2 e o nó é y*3 + x.
+func TestParse(t *testing.T) {
+	l, err := Parse([]byte(`{
+		"title": "Teste",
+		"difficulty": 2,
+		"rows": ["ST.", " .X", "P.P"],
+		"movement": "torre",
+		"walls": [[4, 5]],
+		"hints": true,
+		"enemies": 1,
+		"behaviors": ["patrulheiro", "previsor"],
+		"sides": ["direita"],
+		"weapons": ["teleguiado"],
+		"time": 90,
+		"lives": 4
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sim.Board{
+		Width:      3,
+		Height:     3,
+		Void:       map[int]bool{3: true},
+		Movement:   utils.Orthogonal,
+		Walls:      []utils.Wall{{4, 5}},
+		Hints:      true,
+		Difficulty: 2,
+		Treasure:   7,
+		Traps:      map[int]bool{0: true, 2: true, 5: true, 6: true},
+		TrapTypes:  map[int]sim.TrapType{0: sim.Teleporter, 2: sim.Teleporter, 6: sim.Spike},
+	}
+	if !reflect.DeepEqual(l.Board, want) {
+		t.Errorf("tabuleiro %+v, queria %+v", l.Board, want)
+	}
+	defaults := sim.NewLevel(want)
+	if l.Title != "Teste" || l.Enemies != 1 || l.GameTime != 90*60 || l.Lives != 4 ||
+		l.MemorizeTime != defaults.MemorizeTime || l.Rocks != defaults.Rocks ||
+		!reflect.DeepEqual(l.Behaviors, []sim.BehaviorKind{sim.Patroller, sim.Predictor}) ||
+		!reflect.DeepEqual(l.Sides, []sim.Side{sim.Right}) ||
+		!reflect.DeepEqual(l.Weapons, []sim.WeaponKind{sim.Homing}) {
+		t.Errorf("limites %+v", l)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want error
+	}{
+		{"não é JSON", `{"rows": [`, ErrFormat},
+		{"sem tesouro", `{"difficulty": 1, "rows": ["..", ".."]}`, ErrFormat},
+		{"dois tesouros", `{"difficulty": 1, "rows": ["TT", ".."]}`, ErrFormat},
+		{"linhas tortas", `{"difficulty": 1, "rows": ["..T", ".."]}`, ErrFormat},
+		{"letra desconhecida", `{"difficulty": 1, "rows": [".T", "Z."]}`, ErrFormat},
+		{"movimento desconhecido", `{"difficulty": 1, "rows": [".T", ".."], "movement": "dama"}`, ErrFormat},
+		{"comportamento desconhecido", `{"difficulty": 1, "rows": [".T", ".."], "behaviors": ["dançarino"]}`, ErrFormat},
+		{"lado desconhecido", `{"difficulty": 1, "rows": [".T", ".."], "sides": ["meio"]}`, ErrFormat},
+		{"arma desconhecida", `{"difficulty": 1, "rows": [".T", ".."], "weapons": ["canhão"]}`, ErrFormat},
+		{"parede longe", `{"difficulty": 1, "rows": [".T", ".."], "walls": [[0, 3]]}`, ErrFormat},
+		{"dificuldade", `{"difficulty": 5, "rows": [".T", ".."]}`, ErrFormat},
+		{"limite negativo", `{"difficulty": 1, "rows": [".T", ".."], "rocks": -1}`, ErrFormat},
+		{"sem vidas", `{"difficulty": 1, "rows": [".T", ".."], "lives": 0}`, ErrFormat},
+		{"tesouro cercado", `{"difficulty": 1, "rows": ["..T", "XXX", "..."]}`, ErrUnsolvable},
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt.json)); !errors.Is(err, tt.want) {
+			t.Errorf("%s: erro %v, queria %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+// TestFileRoundTrip grava um nível com Encode e o lê de volta com ReadFile
+func TestFileRoundTrip(t *testing.T) {
+	enemies, time := 2, 90
+	want := File{
+		Title:      "Ida e volta",
+		Difficulty: 2,
+		Rows:       []string{"..T", ".X.", "..S"},
+		Walls:      [][2]int{{0, 1}},
+		Dark:       true,
+		Start:      1,
+		Enemies:    &enemies,
+		Behaviors:  []string{"caçador"},
+		Sides:      []string{"esquerda", "cima"},
+		Weapons:    []string{"leque"},
+		Walkers:    []Walker{{Kind: "batedor", Route: []int{0, 4, 8}}},
+		Time:       &time,
+	}
+	data, err := want.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadFile(fstest.MapFS{"nivel.json": {Data: data}}, "nivel.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lido %+v, queria %+v", got, want)
+	}
+}
+
+// TestLoadCampaign lê um manifesto com os níveis numa subpasta
+func TestLoadCampaign(t *testing.T) {
+	fsys := fstest.MapFS{
+		"niveis/campanha.json": {Data: []byte(`{"levels": ["a.json", "b.json"]}`)},
+		"niveis/a.json":        {Data: []byte(`{"title": "A", "difficulty": 1, "rows": [".T", ".."]}`)},
+		"niveis/b.json":        {Data: []byte(`{"title": "B", "difficulty": 2, "rows": ["T.", ".."]}`)},
+		"niveis/quebrada.json": {Data: []byte(`{"levels": ["a.json", "c.json"]}`)},
+	}
+	levels, err := LoadCampaign(fsys, "niveis/campanha.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(levels) != 2 || levels[0].Title != "A" || levels[1].Title != "B" {
+		t.Errorf("campanha %+v, queria os níveis A e B", levels)
+	}
+	if _, err := LoadCampaign(fsys, "niveis/quebrada.json"); err == nil {
+		t.Error("campanha com um nível que não existe foi lida")
+	}
+}
+
+// TestShippedCampaign confere que a campanha que vai no jogo carrega
+func TestShippedCampaign(t *testing.T) {
+	levels, err := LoadCampaign(os.DirFS("../assets/levels"), "campaign.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(levels) == 0 {
+		t.Error("campanha vazia")
+	}
+}
+
+// TestWalkers confere as rotas dos inimigos do tabuleiro. O tabuleiro tem
+// uma armadilha no nó 4, o do meio.
+func TestWalkers(t *testing.T) {
+	tests := []struct {
+		name   string
+		walker Walker
+		ok     bool
+	}{
+		{"guarda", Walker{Kind: "guarda", Route: []int{0, 2, 8}}, true},
+		{"batedor sobre armadilha", Walker{Kind: "batedor", Route: []int{0, 4}}, true},
+		{"guarda sobre armadilha", Walker{Kind: "guarda", Route: []int{0, 4}}, false},
+		{"rota vazia", Walker{Kind: "guarda"}, false},
+		{"fora do tabuleiro", Walker{Kind: "guarda", Route: []int{0, 9}}, false},
+		{"tipo desconhecido", Walker{Kind: "fantasma", Route: []int{0}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := File{Difficulty: 1, Rows: []string{"..T", ".X.", "..."}, Walkers: []Walker{tt.walker}}
+			l, err := f.Level()
+			if !tt.ok {
+				if !errors.Is(err, ErrFormat) {
+					t.Errorf("erro %v, queria ErrFormat", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			kind, _ := WalkerKind(tt.walker.Kind)
+			want := []sim.WalkerSpec{{Kind: kind, Route: tt.walker.Route}}
+			if !reflect.DeepEqual(l.Walkers, want) {
+				t.Errorf("inimigos %+v, queria %+v", l.Walkers, want)
+			}
+		})
+	}
+}
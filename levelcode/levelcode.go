@@ -0,0 +1,330 @@
+// Package levelcode converte tabuleiros em códigos curtos que podem ser
+// compartilhados entre jogadores, e de volta.
+//
+// Um código é o base32 (sem padding, em grupos de 5 letras) dos bytes:
+//
+//	1 | tamanho | dificuldade | tesouro (2 bytes) | armadilhas (bitset) | checksum (2 bytes)
+//
+// para tabuleiros quadrados e cheios, ou, para os demais formatos:
+//
+//	3 | largura | altura | regras | dificuldade | tesouro (2 bytes) | armadilhas (bitset) | vazios (bitset) | checksum (2 bytes)
+//
+// onde o bit 0 de regras liga a grade hexagonal, os bits 1 e 2 guardam a
+// regra de movimento (utils.Movement), o bit 3 diz que o tabuleiro tem paredes
+// e o bit 4 que elas somem depois da memorização. Com paredes, vem ainda um
+// bitset com um bit por utils.Shape.WallSlots. O bit 5 diz que há armadilhas
+// de outros tipos além do buraco: nesse caso vem, por último, o sim.TrapType
+// de cada armadilha em ordem de nó, 4 bits cada. O bit 6 liga as dicas de
+// armadilhas vizinhas (sim.Board.Hints) e o bit 7 o modo escuro
+// (sim.Board.Dark). A versão 2, ainda aceita por Decode, é igual à 3 sem o
+// byte de regras.
+// O checksum são os 16 bits baixos do CRC-32 de tudo que vem antes dele.
+package levelcode
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+
+	"example/tesourim/sim"
+	"example/tesourim/utils"
+)
+
+// Version é a versão mais nova do formato. Encode só a usa quando o
+// tabuleiro não cabe na versão 1, para os códigos antigos continuarem curtos.
+const Version = 3
+
+const (
+	headerLenV1 = 5
+	headerLenV2 = 6
+	headerLenV3 = 7
+	checksumLen = 2
+	groupLen    = 5
+)
+
+// Bits do byte de regras da versão 3
+const (
+	ruleHex       = 1 << 0
+	ruleMovement  = 3 << 1
+	ruleWalls     = 1 << 3
+	ruleHideWalls = 1 << 4
+	ruleTrapTypes = 1 << 5
+	ruleHints     = 1 << 6
+	ruleDark      = 1 << 7
+)
+
+var (
+	ErrMalformed = errors.New("levelcode: código malformado")
+	ErrVersion   = errors.New("levelcode: versão desconhecida")
+	ErrChecksum  = errors.New("levelcode: checksum inválido")
+	ErrInvalid   = errors.New("levelcode: tabuleiro inválido")
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// MaxLen é o tamanho, com os hífens, do maior código que Encode gera: um
+// tabuleiro hexagonal do maior tamanho, com paredes e armadilhas de vários
+// tipos em todas as células menos a do tesouro
+var MaxLen = func() int {
+	cells := sim.MaxGridSize * sim.MaxGridSize
+	slots := len(sim.Board{Width: sim.MaxGridSize, Height: sim.MaxGridSize, Hex: true}.Shape().WallSlots())
+	bytes := headerLenV3 + 2*((cells+7)/8) + (slots+7)/8 + cells/2 + checksumLen
+	chars := (bytes*8 + 4) / 5
+	return chars + (chars-1)/groupLen
+}()
+
+// Encode gera o código do tabuleiro b
+func Encode(b sim.Board) string {
+	cells := b.Width * b.Height
+	bitsetLen := (cells + 7) / 8
+
+	var data []byte
+	if b.Width == b.Height && len(b.Void) == 0 && !b.Hex && b.Movement == utils.King && len(b.Walls) == 0 && !b.HideWalls && len(b.TrapTypes) == 0 && !b.Hints && !b.Dark {
+		data = make([]byte, headerLenV1, headerLenV1+bitsetLen+checksumLen)
+		data[0] = 1
+		data[1] = byte(b.Width)
+		data[2] = byte(b.Difficulty)
+		binary.BigEndian.PutUint16(data[3:5], uint16(b.Treasure))
+		data = appendBitset(data, b.Traps, cells)
+	} else {
+		data = make([]byte, headerLenV3, headerLenV3+2*bitsetLen+checksumLen)
+		data[0] = Version
+		data[1] = byte(b.Width)
+		data[2] = byte(b.Height)
+		data[3] = byte(b.Movement) << 1
+		if b.Hex {
+			data[3] |= ruleHex
+		}
+		if len(b.Walls) > 0 {
+			data[3] |= ruleWalls
+		}
+		if b.HideWalls {
+			data[3] |= ruleHideWalls
+		}
+		if len(b.TrapTypes) > 0 {
+			data[3] |= ruleTrapTypes
+		}
+		if b.Hints {
+			data[3] |= ruleHints
+		}
+		if b.Dark {
+			data[3] |= ruleDark
+		}
+		data[4] = byte(b.Difficulty)
+		binary.BigEndian.PutUint16(data[5:7], uint16(b.Treasure))
+		data = appendBitset(data, b.Traps, cells)
+		data = appendBitset(data, b.Void, cells)
+		if len(b.Walls) > 0 {
+			data = appendWalls(data, b)
+		}
+		if len(b.TrapTypes) > 0 {
+			data = appendTrapTypes(data, b)
+		}
+	}
+	data = binary.BigEndian.AppendUint16(data, uint16(crc32.ChecksumIEEE(data)))
+
+	raw := encoding.EncodeToString(data)
+	groups := make([]string, 0, len(raw)/groupLen+1)
+	for len(raw) > groupLen {
+		groups = append(groups, raw[:groupLen])
+		raw = raw[groupLen:]
+	}
+	groups = append(groups, raw)
+	return strings.Join(groups, "-")
+}
+
+// Decode lê um código gerado por Encode. Espaços, hífens e letras minúsculas
+// são aceitos, já que o código costuma ser digitado à mão.
+func Decode(code string) (sim.Board, error) {
+	clean := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(code))
+
+	data, err := encoding.DecodeString(clean)
+	if err != nil || len(data) < headerLenV1+checksumLen {
+		return sim.Board{}, ErrMalformed
+	}
+	if data[0] < 1 || data[0] > Version {
+		return sim.Board{}, fmt.Errorf("%w: %d", ErrVersion, data[0])
+	}
+
+	body, sum := data[:len(data)-checksumLen], data[len(data)-checksumLen:]
+	if binary.BigEndian.Uint16(sum) != uint16(crc32.ChecksumIEEE(body)) {
+		return sim.Board{}, ErrChecksum
+	}
+
+	var b sim.Board
+	switch data[0] {
+	case 1:
+		b = sim.Board{
+			Width:      int(body[1]),
+			Height:     int(body[1]),
+			Difficulty: int(body[2]),
+			Treasure:   int(binary.BigEndian.Uint16(body[3:5])),
+		}
+		cells := b.Width * b.Height
+		if len(body) != headerLenV1+(cells+7)/8 {
+			return sim.Board{}, ErrMalformed
+		}
+		b.Traps = readBitset(body[headerLenV1:], cells)
+	default:
+		// A versão 2 não tem o byte de regras
+		n, rules := headerLenV2, byte(0)
+		if data[0] == 3 {
+			n = headerLenV3
+		}
+		if len(body) < n {
+			return sim.Board{}, ErrMalformed
+		}
+		if n == headerLenV3 {
+			rules = body[3]
+			b.Hex = rules&ruleHex != 0
+			b.Movement = utils.Movement(rules & ruleMovement >> 1)
+			b.HideWalls = rules&ruleHideWalls != 0
+			b.Hints = rules&ruleHints != 0
+			b.Dark = rules&ruleDark != 0
+		}
+		b.Width = int(body[1])
+		b.Height = int(body[2])
+		b.Difficulty = int(body[n-3])
+		b.Treasure = int(binary.BigEndian.Uint16(body[n-2 : n]))
+
+		cells := b.Width * b.Height
+		bitsetLen := (cells + 7) / 8
+		if len(body) < n+2*bitsetLen {
+			return sim.Board{}, ErrMalformed
+		}
+		b.Traps = readBitset(body[n:], cells)
+		if void := readBitset(body[n+bitsetLen:], cells); len(void) > 0 {
+			b.Void = void
+		}
+
+		// As paredes dependem dos vazios, que já foram lidos
+		n += 2 * bitsetLen
+		wallsLen := 0
+		if rules&ruleWalls != 0 {
+			slots := b.Shape().WallSlots()
+			wallsLen = (len(slots) + 7) / 8
+			if len(body) >= n+wallsLen {
+				for i := range slots {
+					if body[n+i/8]&(1<<(i%8)) != 0 {
+						b.Walls = append(b.Walls, slots[i])
+					}
+				}
+			}
+		}
+		if len(body) < n+wallsLen || (rules&ruleWalls != 0 && len(b.Walls) == 0) {
+			return sim.Board{}, ErrMalformed
+		}
+		n += wallsLen
+
+		typesLen := 0
+		if rules&ruleTrapTypes != 0 {
+			nodes := trapNodes(b)
+			typesLen = (len(nodes) + 1) / 2
+			if len(body) == n+typesLen {
+				b.TrapTypes = make(map[int]sim.TrapType)
+				for i, node := range nodes {
+					t := sim.TrapType(body[n+i/2] >> (4 * (i % 2)) & 0xf)
+					if !t.Valid() {
+						return sim.Board{}, fmt.Errorf("%w: tipo de armadilha %d", ErrInvalid, t)
+					}
+					if t != sim.Pit {
+						b.TrapTypes[node] = t
+					}
+				}
+			}
+		}
+		if len(body) != n+typesLen || (rules&ruleTrapTypes != 0 && len(b.TrapTypes) == 0) {
+			return sim.Board{}, ErrMalformed
+		}
+	}
+
+	if err := validate(b); err != nil {
+		return sim.Board{}, err
+	}
+	return b, nil
+}
+
+// appendBitset acrescenta a data um bit por célula, ligado nas células de set
+func appendBitset(data []byte, set map[int]bool, cells int) []byte {
+	bits := make([]byte, (cells+7)/8)
+	for node := range set {
+		if set[node] && node >= 0 && node < cells {
+			bits[node/8] |= 1 << (node % 8)
+		}
+	}
+	return append(data, bits...)
+}
+
+// appendWalls acrescenta a data um bit por utils.Shape.WallSlots de b, ligado
+// nos lugares que têm parede
+func appendWalls(data []byte, b sim.Board) []byte {
+	slots := b.Shape().WallSlots()
+	index := make(map[utils.Wall]int, len(slots))
+	for i, slot := range slots {
+		index[slot] = i
+	}
+	set := make(map[int]bool, len(b.Walls))
+	for _, w := range b.Walls {
+		if i, ok := index[utils.NewWall(w[0], w[1])]; ok {
+			set[i] = true
+		}
+	}
+	return appendBitset(data, set, len(slots))
+}
+
+// trapNodes devolve as armadilhas de b em ordem de nó
+func trapNodes(b sim.Board) []int {
+	var nodes []int
+	for node := 0; node < b.Width*b.Height; node++ {
+		if b.Traps[node] {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// appendTrapTypes acrescenta a data o tipo de cada armadilha de b, 4 bits
+// cada, em ordem de nó
+func appendTrapTypes(data []byte, b sim.Board) []byte {
+	nodes := trapNodes(b)
+	types := make([]byte, (len(nodes)+1)/2)
+	for i, node := range nodes {
+		types[i/2] |= byte(b.TrapAt(node)&0xf) << (4 * (i % 2))
+	}
+	return append(data, types...)
+}
+
+// readBitset é o inverso de appendBitset
+func readBitset(bits []byte, cells int) map[int]bool {
+	set := make(map[int]bool)
+	for node := 0; node < cells; node++ {
+		if bits[node/8]&(1<<(node%8)) != 0 {
+			set[node] = true
+		}
+	}
+	return set
+}
+
+func validate(b sim.Board) error {
+	switch {
+	case b.Width < 2 || b.Width > sim.MaxGridSize || b.Height < 2 || b.Height > sim.MaxGridSize:
+		return fmt.Errorf("%w: tamanho %dx%d", ErrInvalid, b.Width, b.Height)
+	case b.Difficulty < 1 || b.Difficulty > 3:
+		return fmt.Errorf("%w: dificuldade %d", ErrInvalid, b.Difficulty)
+	case !b.Movement.Valid() || (b.Hex && b.Movement != utils.King):
+		return fmt.Errorf("%w: regra de movimento %d", ErrInvalid, b.Movement)
+	case b.Treasure < 0 || b.Treasure >= b.Width*b.Height || b.Void[b.Treasure]:
+		return fmt.Errorf("%w: tesouro fora do grid", ErrInvalid)
+	case !b.Solvable():
+		return fmt.Errorf("%w: tesouro inalcançável", ErrInvalid)
+	}
+	return nil
+}
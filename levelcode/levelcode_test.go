@@ -0,0 +1,211 @@
+package levelcode
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"reflect"
+	"strings"
+	"testing"
+
+	"example/tesourim/sim"
+	"example/tesourim/utils"
+)
+
+// sameBoard diz se a e b são o mesmo tabuleiro para o código: mapas vazios
+// e nil contam como iguais
+func sameBoard(a, b sim.Board) bool {
+	sameSet := func(x, y map[int]bool) bool {
+		for node := range x {
+			if x[node] != y[node] {
+				return false
+			}
+		}
+		for node := range y {
+			if x[node] != y[node] {
+				return false
+			}
+		}
+		return true
+	}
+	sameWalls := func(x, y []utils.Wall) bool {
+		set := make(map[utils.Wall]bool)
+		for _, w := range x {
+			set[utils.NewWall(w[0], w[1])] = true
+		}
+		for _, w := range y {
+			if !set[utils.NewWall(w[0], w[1])] {
+				return false
+			}
+		}
+		return len(x) == len(y)
+	}
+	sameTypes := func(x, y map[int]sim.TrapType) bool {
+		return len(x) == len(y) && (len(x) == 0 || reflect.DeepEqual(x, y))
+	}
+	return a.Width == b.Width && a.Height == b.Height && a.Hex == b.Hex && a.Movement == b.Movement &&
+		a.HideWalls == b.HideWalls && a.Hints == b.Hints && a.Dark == b.Dark &&
+		a.Difficulty == b.Difficulty && a.Treasure == b.Treasure &&
+		sameSet(a.Traps, b.Traps) && sameSet(a.Void, b.Void) && sameTypes(a.TrapTypes, b.TrapTypes) &&
+		sameWalls(a.Walls, b.Walls)
+}
+
+// generate é sim.GenerateBoard para formatos que têm entrada
+func generate(t *testing.T, seed int64, shape sim.Board, dificulty, walls int) sim.Board {
+	t.Helper()
+	b, err := sim.GenerateBoard(seed, shape, dificulty, walls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// raw devolve os bytes do código code
+func raw(t *testing.T, code string) []byte {
+	t.Helper()
+	data, err := encoding.DecodeString(strings.ReplaceAll(code, "-", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// rewrite monta um código com os bytes data, refazendo o checksum
+func rewrite(data []byte) string {
+	body := data[:len(data)-checksumLen]
+	data = binary.BigEndian.AppendUint16(body, uint16(crc32.ChecksumIEEE(body)))
+	return encoding.EncodeToString(data)
+}
+
+func TestRoundTrip(t *testing.T) {
+	void := map[int]bool{0: true, 6: true}
+	tests := []struct {
+		name    string
+		shape   sim.Board
+		walls   int
+		version byte
+	}{
+		{"quadrado", sim.SquareBoard(6), 0, 1},
+		{"quadrado grande", sim.SquareBoard(sim.MaxGridSize), 0, 1},
+		{"retângulo", sim.Board{Width: 7, Height: 5}, 0, 3},
+		{"vazios", sim.Board{Width: 7, Height: 6, Void: void}, 0, 3},
+		{"hexagonal", sim.Board{Width: 6, Height: 6, Hex: true}, 0, 3},
+		{"cavalo", sim.Board{Width: 6, Height: 6, Movement: utils.Knight}, 0, 3},
+		{"paredes", sim.Board{Width: 6, Height: 6}, 4, 3},
+		{"paredes escondidas", sim.Board{Width: 6, Height: 6, HideWalls: true}, 4, 3},
+		{"dicas", sim.Board{Width: 6, Height: 6, Hints: true}, 0, 3},
+		{"escuro", sim.Board{Width: 6, Height: 6, Dark: true}, 0, 3},
+	}
+	for _, tt := range tests {
+		for dificulty := 1; dificulty <= 3; dificulty++ {
+			b := generate(t, int64(dificulty), tt.shape, dificulty, tt.walls)
+			code := Encode(b)
+			version := tt.version
+			if version == 1 && len(b.TrapTypes) > 0 {
+				version = 3 // Armadilhas de outros tipos não cabem na versão 1
+			}
+			if got := raw(t, code)[0]; got != version {
+				t.Errorf("%s, dificuldade %d: versão %d, queria %d", tt.name, dificulty, got, version)
+			}
+			got, err := Decode(code)
+			if err != nil {
+				t.Errorf("%s, dificuldade %d: %v", tt.name, dificulty, err)
+				continue
+			}
+			if !sameBoard(got, b) {
+				t.Errorf("%s, dificuldade %d: lido %+v, queria %+v", tt.name, dificulty, got, b)
+			}
+			// O código é digitado à mão: minúsculas e espaços valem
+			if _, err := Decode(strings.ToLower(strings.ReplaceAll(code, "-", " "))); err != nil {
+				t.Errorf("%s, dificuldade %d: digitado à mão: %v", tt.name, dificulty, err)
+			}
+		}
+	}
+}
+
+// TestVersion2 lê um código da versão 2, que é a 3 sem o byte de regras
+func TestVersion2(t *testing.T) {
+	b := generate(t, 1, sim.Board{Width: 7, Height: 5, Void: map[int]bool{34: true}}, 1, 0)
+	data := raw(t, Encode(b))
+	if data[0] != 3 || data[3] != 0 {
+		t.Fatalf("versão %d com regras %b; o teste precisa de um tabuleiro sem regras", data[0], data[3])
+	}
+	v2 := append([]byte{2}, data[1:3]...)
+	v2 = append(v2, data[4:]...)
+	got, err := Decode(rewrite(v2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameBoard(got, b) {
+		t.Errorf("lido %+v, queria %+v", got, b)
+	}
+}
+
+// TestChecksum troca um bit de cada byte do código e confere que o CRC
+// recusa todos
+func TestChecksum(t *testing.T) {
+	boards := []sim.Board{
+		generate(t, 1, sim.SquareBoard(6), 1, 0),
+		generate(t, 2, sim.Board{Width: 7, Height: 6, Hex: true, Dark: true}, 3, 0),
+	}
+	for _, b := range boards {
+		data := raw(t, Encode(b))
+		for i := 1; i < len(data); i++ {
+			for bit := 0; bit < 8; bit++ {
+				corrupt := append([]byte(nil), data...)
+				corrupt[i] ^= 1 << bit
+				if _, err := Decode(encoding.EncodeToString(corrupt)); !errors.Is(err, ErrChecksum) {
+					t.Errorf("byte %d, bit %d trocado: erro %v, queria ErrChecksum", i, bit, err)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	valid := raw(t, Encode(generate(t, 1, sim.SquareBoard(6), 1, 0)))
+	version := func(v byte) string {
+		data := append([]byte(nil), valid...)
+		data[0] = v
+		return rewrite(data)
+	}
+	truncated := rewrite(append(append([]byte(nil), valid[:len(valid)-3]...), 0, 0))
+	badDifficulty := append([]byte(nil), valid...)
+	badDifficulty[2] = 7
+
+	tests := []struct {
+		name string
+		code string
+		want error
+	}{
+		{"vazio", "", ErrMalformed},
+		{"curto", "AEDA", ErrMalformed},
+		{"fora do base32", "AEDAC-ABBF!-NB2VI", ErrMalformed},
+		{"versão 0", version(0), ErrVersion},
+		{"versão nova", version(Version + 1), ErrVersion},
+		{"cortado", truncated, ErrMalformed},
+		{"dificuldade", rewrite(badDifficulty), ErrInvalid},
+	}
+	for _, tt := range tests {
+		if _, err := Decode(tt.code); !errors.Is(err, tt.want) {
+			t.Errorf("%s: erro %v, queria %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+// TestMaxLen monta o maior tabuleiro possível e confere que o código dele
+// tem exatamente MaxLen caracteres
+func TestMaxLen(t *testing.T) {
+	for _, hex := range []bool{true, false} {
+		b := sim.Board{Width: sim.MaxGridSize, Height: sim.MaxGridSize, Hex: hex, Hints: true, Dark: true, Difficulty: 3}
+		b.Traps, b.TrapTypes = make(map[int]bool), make(map[int]sim.TrapType)
+		for node := 1; node < b.Width*b.Height; node++ {
+			b.Traps[node], b.TrapTypes[node] = true, sim.Spike
+		}
+		b.Walls = []utils.Wall{b.Shape().WallSlots()[0]}
+		got := len(Encode(b))
+		if got > MaxLen || hex && got != MaxLen {
+			t.Errorf("hex = %v: código com %d caracteres, MaxLen é %d", hex, got, MaxLen)
+		}
+	}
+}
@@ -0,0 +1,662 @@
+package main
+
+import (
+	"example/tesourim/levelcode"
+	"example/tesourim/replay"
+	"example/tesourim/save"
+	"example/tesourim/sim"
+	"example/tesourim/utils"
+	"fmt"
+	"image/color"
+	"log"
+	"strconv"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+)
+
+// PlayScene adapta o sim.World ao Ebiten: lê o teclado e desenha o estado
+type PlayScene struct{
+	game         *Game
+	world        *sim.World
+	playerSprite *PlayerSprite
+	enemySprites map[*sim.Enemy]*EnemySprite // Sprite de cada inimigo vivo no mundo
+	queued       sim.Input      // Ações pedidas pelos menus, aplicadas no próximo tick
+	recording    *replay.Replay // Gravação da partida (nil se não estiver gravando)
+	player       *replay.Player // Reprodução de um replay (nil no jogo normal)
+	savedRound   int            // Último tabuleiro salvo automaticamente
+	lastState    sim.State      // Estado no tick anterior, para as estatísticas
+	view         boardView      // Posição do tabuleiro no último Draw, para o mouse
+	playtest     bool           // Teste de um nível do editor: não salva nada e volta ao editor no fim
+	graph        map[int][]int  // Grafo do tabuleiro de cacheWorld na rodada cacheRound
+	route        []int          // Rota ótima do mesmo tabuleiro
+	cacheWorld   *sim.World
+	cacheRound   int
+}
+
+// newPlayScene começa a jogar no mundo w
+func newPlayScene(game *Game, w *sim.World) *PlayScene {
+	return &PlayScene{
+		game:         game,
+		world:        w,
+		playerSprite: NewPlayerSprite(100, 100, 4), // 4 frames of animation
+		enemySprites: make(map[*sim.Enemy]*EnemySprite),
+		savedRound:   w.Round,
+		lastState:    w.State,
+	}
+}
+
+// newReplayScene reproduz o replay r
+func newReplayScene(game *Game, r *replay.Replay) *PlayScene {
+	player := replay.NewPlayer(r, game.campaign)
+	s := newPlayScene(game, player.World())
+	s.player = player
+	return s
+}
+
+// drawEnemy desenha o inimigo e seus projéteis. No modo escuro, um inimigo
+// longe da luz é só uma silhueta e os projéteis dele não aparecem.
+func drawEnemy(screen *ebiten.Image, w *sim.World, e *sim.Enemy, sprite *EnemySprite, view boardView) {
+	nodeSize := int(view.nodeSize)
+	// Desenha o inimigo apenas se estiver vivo
+	if e.Alive {
+		ex, ey := e.Position(w.Level.Board)
+		enemyScreenX, enemyScreenY := view.toScreen(ex, ey)
+		enemyScreenX -= float64(nodeSize) / 2
+		enemyScreenY -= float64(nodeSize) / 2
+		if !w.Lit(ex, ey) {
+			ebitenutil.DrawRect(screen, enemyScreenX, enemyScreenY, float64(nodeSize), float64(nodeSize), color.RGBA{40, 40, 40, 255})
+		} else if sprite != nil {
+			sprite.Draw(screen, enemyScreenX, enemyScreenY + 20, nodeSize)
+		} else {
+			ebitenutil.DrawRect(screen, enemyScreenX, enemyScreenY, float64(nodeSize), float64(nodeSize), color.RGBA{255, 0, 0, 255})
+		}
+		// Comportamentos diferentes do original ganham o nome embaixo
+		if e.Kind != sim.Wanderer && w.Lit(ex, ey) {
+			name := e.Kind.Name()
+			text.Draw(screen, name, basicfont.Face7x13, int(enemyScreenX)+nodeSize/2-len(name)*7/2, int(enemyScreenY)+nodeSize+14, color.White)
+		}
+	}
+
+	// Desenha os projéteis
+	for _, bullet := range e.Bullets {
+		if bullet.Active && w.Lit(bullet.X, bullet.Y) {
+			bulletScreenX, bulletScreenY := view.toScreen(bullet.X, bullet.Y)
+			// Projéteis refletidos são azuis
+			bulletColor := bullet.Color
+			if bullet.Reflected {
+				bulletColor = color.RGBA{0, 0, 255, 255}
+			}
+			ebitenutil.DrawCircle(screen, bulletScreenX, bulletScreenY, max(bullet.Radius*view.nodeSize, 4), bulletColor)
+		}
+	}
+}
+
+// walkerColors é a cor de cada tipo de inimigo do tabuleiro
+var walkerColors = [sim.WalkerKinds]color.RGBA{
+	sim.Guard: {150, 0, 200, 255},
+	sim.Scout: {0, 170, 170, 255},
+}
+
+// drawWalker desenha um inimigo do tabuleiro como um losango, com um anel
+// vermelho enquanto persegue o jogador. Longe da luz, é só uma silhueta.
+func drawWalker(screen *ebiten.Image, w *sim.World, k *sim.Walker, view boardView) {
+	x, y := k.Position(w)
+	cx, cy := view.toScreen(x, y)
+	r := float32(view.nodeSize * 0.35)
+	clr := color.Color(walkerColors[k.Kind])
+	if !w.Lit(x, y) {
+		clr = color.RGBA{40, 40, 40, 255}
+	}
+
+	var path vector.Path
+	path.MoveTo(float32(cx), float32(cy)-r)
+	path.LineTo(float32(cx)+r, float32(cy))
+	path.LineTo(float32(cx), float32(cy)+r)
+	path.LineTo(float32(cx)-r, float32(cy))
+	path.Close()
+	fillPath(screen, &path, clr)
+	if k.Chasing && w.Lit(x, y) {
+		vector.StrokeCircle(screen, float32(cx), float32(cy), r+3, 2, color.RGBA{255, 0, 0, 255}, true)
+	}
+}
+
+func (s *PlayScene) Draw(screen *ebiten.Image) {
+	w := s.world
+	board := w.Level.Board
+	gridSize := board.Size()
+	// Get screen dimensions to center the grid
+	sw, sh := screen.Bounds().Dx(), screen.Bounds().Dy()
+	frameX := (sw - gridWidth) / 2
+	frameY := (sh - gridHeight) / 2
+	// O tabuleiro é centralizado dentro da área do grid
+	view := newBoardView(board, frameX, frameY)
+	s.view = view
+	nodeSize := int(view.nodeSize)
+	
+	// Draw title and instructions
+	face := basicfont.Face7x13
+	title := "Tesourim"
+	if w.Level.Title != "" {
+		title += " - " + w.Level.Title
+	}
+	instructions := "ESC/P para pausar | " + movementHelp(board) + " | level: " + fmt.Sprintf("%d", gridSize - 5)
+	if !s.game.settings.ShowControls {
+		instructions = "level: " + fmt.Sprintf("%d", gridSize - 5)
+	}
+	
+	// Calculate text position for center alignment
+	titleBounds := font.MeasureString(mplusNormalFont, title)
+	titleX := float64(sw/2 - titleBounds.Round()/2)
+	
+	// Draw texts
+	text.Draw(screen, title, mplusNormalFont, int(titleX), frameY-20, color.White)
+	text.Draw(screen, instructions, face, frameX, frameY-5, color.White)
+	text.Draw(screen, fmt.Sprintf("Seed: %d", w.Seed), face, frameX, frameY+gridHeight+18, color.White)
+
+	if w.State == sim.Playing {
+		timeLeft := fmt.Sprintf("Tempo: %d", w.GameTimer/60)
+		text.Draw(screen, timeLeft, mplusBoldFont, sw-180, 40, color.White)
+		
+		// Desenha as vidas restantes
+		lives := fmt.Sprintf("Vidas: %d", w.Lives)
+		text.Draw(screen, lives, mplusBoldFont, 30, 40, color.White)
+
+		// Opcional: Desenha corações para representar as vidas
+		for i := 0; i < w.Lives; i++ {
+			heartX := float64(60 + (i * 20))
+			ebitenutil.DrawCircle(screen, heartX, 55, 8, color.RGBA{255, 0, 0, 255})
+		}
+	}
+	// Draw grid
+	for row := 0; row < board.Height; row++ {
+		for col := 0; col < board.Width; col++ {
+			// Invert the row to start from bottom
+			invertedRow := (board.Height - 1) - row
+			node := board.Node(col, invertedRow)
+			// Células vazias não fazem parte do tabuleiro
+			if board.Void[node] {
+				continue
+			}
+
+			// Determine the color for this cell
+			var clr color.Color
+			if w.ShowTraps {
+				if w.Level.Traps[node] {
+					clr = board.TrapAt(node).Info().Color // Cada tipo de armadilha tem sua cor
+				} else if node == w.Level.Treasure {
+					clr = color.RGBA{0, 255, 0, 255} // Green for the treasure
+				}else {
+					clr = color.RGBA{200, 200, 200, 255} // Gray for normal nodes
+				}
+			} else {
+					clr = color.RGBA{200, 200, 200, 255} // Gray for all nodes when hidden
+			}
+			if w.FallenTraps[node] {
+				clr = color.RGBA{128, 128, 128, 255} // grey for fallen traps
+			} else if w.Cracked[node] {
+				clr = color.RGBA{170, 150, 120, 255} // Piso frágil já rachado
+			}
+
+
+			// Draw the node (square or hexagon) with its outline
+			view.drawCell(screen, col, invertedRow, clr)
+			// No modo escuro, as células longe da luz ficam na sombra
+			if !w.LitNode(node) {
+				view.drawCell(screen, col, invertedRow, color.RGBA{0, 0, 0, 220})
+			}
+		}
+	}
+	if w.ShowTraps {
+		drawTrapLegend(screen, board)
+	}
+	// Bandeiras e dicas, como as células, só aparecem na luz
+	for node := range w.Flags {
+		if w.LitNode(node) {
+			view.drawFlag(screen, node)
+		}
+	}
+	// No modo com dicas, as células conhecidas mostram as armadilhas em volta
+	if board.Hints {
+		graph, _ := s.boardCache()
+		for node := range w.Visited {
+			if !w.LitNode(node) {
+				continue
+			}
+			label := strconv.Itoa(utils.AdjacentTraps(graph, board.Traps, node))
+			cx, cy := view.nodeCenter(node)
+			text.Draw(screen, label, basicfont.Face7x13, int(cx)-3*len(label), int(cy)+5, color.Black)
+		}
+	}
+	// Paredes aparecem na memorização e, se não forem escondidas, o tempo
+	// todo; no escuro, só as que estão na luz
+	if w.ShowTraps || !board.HideWalls {
+		for _, wall := range board.Walls {
+			if w.LitNode(wall[0]) || w.LitNode(wall[1]) {
+				view.drawWall(screen, wall[0], wall[1])
+			}
+		}
+	}
+	// Ao vencer o nível, mostra a rota ótima da entrada até o tesouro. Na
+	// derrota não: o R joga o mesmo tabuleiro de novo.
+	if w.State == sim.Won {
+		_, route := s.boardCache()
+		for i := 1; i < len(route); i++ {
+			x1, y1 := view.nodeCenter(route[i-1])
+			x2, y2 := view.nodeCenter(route[i])
+			vector.StrokeLine(screen, float32(x1), float32(y1), float32(x2), float32(y2), 4, color.RGBA{0, 160, 0, 255}, true)
+		}
+	}
+	// Draw the player
+	if w.PlayerX >= 0 && w.PlayerY >= -1 {
+        playerScreenX, playerScreenY := view.cellCenter(w.PlayerX, w.PlayerY)
+        s.playerSprite.Draw(screen, playerScreenX-float64(nodeSize)/2, playerScreenY-float64(nodeSize)/2, nodeSize)
+    }
+	
+	// Draw aiming crosshair when in aiming mode
+	if w.Aiming {
+		s.playerSprite.SetState(PlayerStateAiming)
+		aimScreenX, aimScreenY := view.cellCenter(w.AimX, w.AimY)
+		
+		// Draw crosshair
+		ebitenutil.DrawLine(screen, aimScreenX-10, aimScreenY, aimScreenX+10, aimScreenY, color.RGBA{255, 0, 0, 255})
+		ebitenutil.DrawLine(screen, aimScreenX, aimScreenY-10, aimScreenX, aimScreenY+10, color.RGBA{255, 0, 0, 255})
+	} else {
+		s.playerSprite.SetState(PlayerStateIdle)
+	}
+
+	// Draw rocks counter
+	if w.State == sim.Playing {
+		rocks := fmt.Sprintf("Pedras: %d", w.RocksLeft)
+		text.Draw(screen, rocks, mplusBoldFont, 30, 90, color.White)
+
+		for i := 0; i < w.RocksLeft; i++ {
+			rockX := float64(60 + (i * 20))
+			ebitenutil.DrawCircle(screen, rockX, 105, 8, color.RGBA{128, 128, 128, 255})
+		}
+	}
+	// Tochas só servem no modo escuro
+	if w.State == sim.Playing && board.Dark {
+		torches := fmt.Sprintf("Tochas: %d", w.TorchesLeft)
+		if w.TorchTimer > 0 {
+			torches += fmt.Sprintf(" (acesa: %d)", w.TorchTimer/60)
+		}
+		text.Draw(screen, torches, mplusBoldFont, 30, 140, color.White)
+	}
+
+	// Draw active rocks
+	for _, rock := range w.Rocks {
+		if rock.Active {
+			rockScreenX, rockScreenY := view.toScreen(rock.X, rock.Y)
+			ebitenutil.DrawCircle(screen, rockScreenX, rockScreenY, 5, color.RGBA{139, 69, 19, 255})
+		}
+	}
+
+	// Draw revealed treasure
+	for _, rock := range w.Rocks {
+		for node := range rock.Revealed {
+			if node != w.Level.Treasure {
+				continue
+			}
+			view.drawCell(screen, node%board.Width, node/board.Width, color.RGBA{0, 255, 0, 255})
+		}
+	}
+	
+	// Draw game state message if exists
+	if w.Message != "" {
+		msgBounds := font.MeasureString(mplusNormalFont, w.Message)
+		msgX := float64(sw/2 - msgBounds.Round()/2)
+		text.Draw(screen, w.Message, mplusNormalFont, int(msgX), sh/2, color.RGBA{255, 0, 255, 255})
+	}
+	if w.State == sim.Playing {
+		// Desenha todos os inimigos
+		for _, e := range w.Enemies {
+			drawEnemy(screen, w, e, s.enemySprites[e], view)
+		}
+		for _, k := range w.Walkers {
+			drawWalker(screen, w, k, view)
+		}
+	}
+
+	// Mostra o código do nível na tela de vitória para desafiar outros jogadores
+	if w.State == sim.Won {
+		code := "Código do nível: " + levelcode.Encode(w.Level.Board)
+		codeBounds := font.MeasureString(mplusBoldFont, code)
+		text.Draw(screen, code, mplusBoldFont, sw/2-codeBounds.Round()/2, sh/2+40, color.White)
+	}
+	if s.player != nil {
+		s.drawReplayStatus(screen)
+	} else if s.game.settings.ShowControls {
+		controls := "L: carregar nível por código | F/botão direito: bandeira"
+		if board.Dark {
+			controls += " | T: tocha"
+		}
+		text.Draw(screen, controls, face, frameX, frameY+gridHeight+34, color.White)
+	}
+}
+
+// movementHelp explica as teclas de movimento do tabuleiro b
+func movementHelp(b sim.Board) string {
+	switch {
+	case b.Hex:
+		return "A/D para os lados | Q/E e Z/C para as diagonais"
+	case b.Movement == utils.Orthogonal:
+		return "Só na horizontal e vertical: WASD/Setas"
+	case b.Movement == utils.Diagonal:
+		return "Só na diagonal: QEZC"
+	case b.Movement == utils.Knight:
+		return "Saltos do cavalo: QWE/A D/ZSC | Setas na entrada"
+	}
+	return "Use WASD/Setas para mover | QEZC para diagonais"
+}
+
+// load troca o nível atual pelo tabuleiro b. A troca passa pelo próximo
+// sim.Input para também ficar registrada nos replays.
+func (s *PlayScene) load(b sim.Board) {
+	s.queued.Load = &b
+}
+
+// resume tira o jogo da pausa no próximo tick
+func (s *PlayScene) resume() {
+	s.queued.Pause = true
+}
+
+// restart reinicia o nível atual no próximo tick
+func (s *PlayScene) restart() {
+	s.queued.Restart = true
+}
+
+// knightKeys são os saltos do cavalo, em volta de WASD: Q/W/E em cima,
+// A/D nos lados e Z/S/C embaixo. A ordem é fixa: com duas letras no mesmo
+// frame, vale sempre a última da lista.
+var knightKeys = []struct {
+	key  ebiten.Key
+	jump [2]int
+}{
+	{ebiten.KeyW, [2]int{-1, 2}}, {ebiten.KeyE, [2]int{1, 2}},
+	{ebiten.KeyQ, [2]int{-2, 1}}, {ebiten.KeyD, [2]int{2, 1}},
+	{ebiten.KeyA, [2]int{-2, -1}}, {ebiten.KeyC, [2]int{2, -1}},
+	{ebiten.KeyZ, [2]int{-1, -2}}, {ebiten.KeyS, [2]int{1, -2}},
+}
+
+// readInput traduz as teclas pressionadas neste frame em um sim.Input, de
+// acordo com a grade e a regra de movimento do tabuleiro b
+func readInput(b sim.Board) sim.Input {
+	var in sim.Input
+	if b.Movement == utils.Knight && !b.Hex {
+		// As setas andam uma célula (na linha de entrada) e as letras saltam
+		for _, k := range knightKeys {
+			if inpututil.IsKeyJustPressed(k.key) {
+				in.MoveX, in.MoveY = k.jump[0], k.jump[1]
+			}
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyLeft) {
+			in.MoveX, in.MoveY = -1, 0
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyRight) {
+			in.MoveX, in.MoveY = 1, 0
+		}
+		readActions(&in)
+		return in
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyLeft) || inpututil.IsKeyJustPressed(ebiten.KeyA) {
+		in.MoveX--
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyRight) || inpututil.IsKeyJustPressed(ebiten.KeyD) {
+		in.MoveX++
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) || inpututil.IsKeyJustPressed(ebiten.KeyW) {
+		in.MoveY++
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyDown) || inpututil.IsKeyJustPressed(ebiten.KeyS) {
+		in.MoveY--
+	}
+	// Diagonais
+	if inpututil.IsKeyJustPressed(ebiten.KeyQ) { // Up-left
+		in.MoveX, in.MoveY = -1, 1
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyE) { // Up-right
+		in.MoveX, in.MoveY = 1, 1
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyZ) { // Down-left
+		in.MoveX, in.MoveY = -1, -1
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyC) { // Down-right
+		in.MoveX, in.MoveY = 1, -1
+	}
+	readActions(&in)
+	return in
+}
+
+// readFlag marca a célula sob o mouse com o botão direito, ou com F a célula
+// da mira (ou sob o mouse, fora da mira)
+func (s *PlayScene) readFlag(in *sim.Input) {
+	w := s.world
+	key := inpututil.IsKeyJustPressed(ebiten.KeyF)
+	switch {
+	case key && w.Aiming:
+		in.Flag, in.FlagX, in.FlagY = true, w.AimX, w.AimY
+	case key || inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight):
+		if s.view.nodeSize == 0 {
+			return
+		}
+		in.FlagX, in.FlagY = s.view.cellAt(ebiten.CursorPosition())
+		in.Flag = w.Level.Inside(in.FlagX, in.FlagY)
+	}
+}
+
+// readActions lê as teclas que não dependem da regra de movimento
+func readActions(in *sim.Input) {
+	in.Aim = inpututil.IsKeyJustPressed(ebiten.KeyControl)
+	in.Throw = inpututil.IsKeyJustPressed(ebiten.KeySpace)
+	in.Reflect = inpututil.IsKeyJustPressed(ebiten.KeyV)
+	in.Restart = inpututil.IsKeyJustPressed(ebiten.KeyR)
+	in.Advance = inpututil.IsKeyJustPressed(ebiten.KeyEnter)
+	in.Pause = inpututil.IsKeyJustPressed(ebiten.KeyP) || inpututil.IsKeyJustPressed(ebiten.KeyEscape)
+	in.Torch = inpututil.IsKeyJustPressed(ebiten.KeyT)
+}
+
+// Update lê a entrada e avança a simulação em um tick
+func (s *PlayScene) Update() error {
+	if s.player != nil {
+		return s.updateReplay()
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyL) {
+		s.game.Push(newCodeScene(s.game, func(b sim.Board) {
+			s.load(b)
+			s.game.Pop()
+		}))
+		return nil
+	}
+
+	in := readInput(s.world.Level.Board)
+	s.readFlag(&in)
+	// Perder o foco da janela também pausa
+	if !ebiten.IsFocused() && s.world.State != sim.Paused {
+		in.Pause = true
+	}
+	if s.queued != (sim.Input{}) {
+		in, s.queued = s.queued, sim.Input{}
+	}
+	// No teste do editor, ENTER depois de vencer volta ao editor
+	if s.playtest && s.world.State == sim.Won && in.Advance {
+		s.game.Pop()
+		return nil
+	}
+	if s.recording != nil {
+		s.recording.Record(in)
+	}
+	s.world.Step(in)
+	s.updateStats()
+	// Enquanto o jogo estiver pausado, o menu de pausa fica por cima
+	if s.world.State == sim.Paused {
+		s.game.Push(newPauseMenu(s.game, s))
+	}
+	if s.world.Over {
+		s.saveProgress()
+		if s.playtest {
+			s.game.Pop()
+		} else {
+			s.game.Switch(newMainMenu(s.game))
+		}
+		return nil
+	}
+	// Salva automaticamente sempre que um novo tabuleiro começa
+	if s.world.Round != s.savedRound {
+		s.savedRound = s.world.Round
+		s.saveProgress()
+	}
+	s.syncSprites()
+	return nil
+}
+
+// updateStats conta vitórias e derrotas quando o estado do mundo muda
+func (s *PlayScene) updateStats() {
+	state := s.world.State
+	if state == s.lastState || s.playtest {
+		return
+	}
+	s.lastState = state
+	stats := &s.game.stats
+	switch state {
+	case sim.Won:
+		stats.LevelsWon++
+		stats.BestLevel = max(stats.BestLevel, s.world.Level.Size()-5)
+		if s.game.settings.CountFlags {
+			correct, placed := s.world.FlagScore()
+			stats.FlagsCorrect += correct
+			stats.FlagsPlaced += placed
+		}
+	case sim.Lost:
+		stats.Losses++
+	default:
+		return
+	}
+	if err := save.WriteStats(*stats); err != nil {
+		log.Println(err)
+	}
+}
+
+// saveProgress grava o progresso atual e a gravação da partida, se houver;
+// replays e testes do editor não mexem no save
+func (s *PlayScene) saveProgress() {
+	if s.recording != nil && s.game.recordPath != "" {
+		if err := s.recording.Save(s.game.recordPath); err != nil {
+			log.Println(err)
+		}
+	}
+	if s.player != nil || s.playtest {
+		return
+	}
+	var err error
+	if s.world.Over {
+		err = save.Delete()
+	} else {
+		err = save.Write(s.world.Seed, s.world.Progress())
+	}
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// updateReplay trata os controles da reprodução de um replay
+func (s *PlayScene) updateReplay() error {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		return ebiten.Termination
+	}
+	p := s.player
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		p.Paused = !p.Paused
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyPeriod) || inpututil.IsKeyJustPressed(ebiten.KeyRight) {
+		p.StepFrame()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.Key1) {
+		p.Speed = 1
+	}
+	if inpututil.IsKeyJustPressed(ebiten.Key2) {
+		p.Speed = 2
+	}
+	if inpututil.IsKeyJustPressed(ebiten.Key4) {
+		p.Speed = 4
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyN) {
+		p.Seek(p.World().Round + 1)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyB) {
+		p.Seek(p.World().Round - 1)
+	}
+	p.Update()
+	s.world = p.World()
+	s.syncSprites()
+	return nil
+}
+
+// drawReplayStatus mostra a posição e os controles da reprodução
+func (s *PlayScene) drawReplayStatus(screen *ebiten.Image) {
+	tick, total := s.player.Tick()
+	status := fmt.Sprintf("REPLAY  tick %d/%d  nível %d/%d  %dx", tick, total, s.world.Round, s.player.Rounds(), s.player.Speed)
+	if s.player.Paused {
+		status += "  [pausado]"
+	} else if s.player.Done() {
+		status += "  [fim]"
+	}
+	text.Draw(screen, status, mplusBoldFont, 30, screen.Bounds().Dy()-60, color.RGBA{255, 255, 0, 255})
+	help := "SPACE pausar | . ou -> avançar um quadro | 1/2/4 velocidade | N/B próximo/anterior nível | ESC sair"
+	text.Draw(screen, help, basicfont.Face7x13, 30, screen.Bounds().Dy()-30, color.White)
+}
+
+// boardCache devolve o grafo e a rota ótima do tabuleiro atual, montados
+// uma vez por nível em vez de a cada quadro
+func (s *PlayScene) boardCache() (map[int][]int, []int) {
+	if s.graph == nil || s.cacheWorld != s.world || s.cacheRound != s.world.Round {
+		s.graph, s.route = s.world.Graph(), s.world.OptimalRoute()
+		s.cacheWorld, s.cacheRound = s.world, s.world.Round
+	}
+	return s.graph, s.route
+}
+
+// syncSprites anima o jogador e mantém um sprite por inimigo do mundo atual
+func (s *PlayScene) syncSprites() {
+	sprites := make(map[*sim.Enemy]*EnemySprite, len(s.world.Enemies))
+	for _, e := range s.world.Enemies {
+		sprite, ok := s.enemySprites[e]
+		if !ok {
+			sprite = NewEnemySprite(100, 60, 10) // Ajuste os valores conforme o tamanho do seu sprite
+		}
+		sprite.Update()
+		// Define o estado do sprite baseado no movimento
+		if player, _ := e.Track(s.world); e.Pos != player {
+			sprite.SetState(EnemyStateMoving)
+		} else {
+			sprite.SetState(EnemyStateIdle)
+		}
+		sprites[e] = sprite
+	}
+	s.enemySprites = sprites
+
+	s.playerSprite.Update()
+}
+
+// drawTrapLegend lista as cores dos tipos de armadilha que existem no tabuleiro
+func drawTrapLegend(screen *ebiten.Image, b sim.Board) {
+	var present [sim.TrapTypes]bool
+	for node := range b.Traps {
+		present[b.TrapAt(node)] = true
+	}
+	y := 150
+	for t, ok := range present {
+		if !ok {
+			continue
+		}
+		info := sim.TrapType(t).Info()
+		ebitenutil.DrawRect(screen, 30, float64(y-11), 12, 12, info.Color)
+		text.Draw(screen, info.Name, basicfont.Face7x13, 48, y, color.White)
+		y += 20
+	}
+}
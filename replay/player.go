@@ -0,0 +1,101 @@
+package replay
+
+import "example/tesourim/sim"
+
+// Player reproduz um replay com pausa, avanço quadro a quadro, velocidade
+// variável e busca por nível
+type Player struct {
+	Paused bool
+	Speed  int // Ticks simulados por quadro (1, 2 ou 4)
+
+	replay   *Replay
+	campaign []sim.Level
+	world    *sim.World
+	tick     int
+	rounds   []int // Tick em que cada tabuleiro da partida começou
+}
+
+// NewPlayer prepara a reprodução. O replay é simulado uma vez por inteiro para
+// descobrir onde cada nível começa, o que permite buscar níveis depois.
+// campaign é a campanha do jogo, usada se a partida gravada começou por ela e
+// o replay não trouxer os próprios níveis.
+func NewPlayer(r *Replay, campaign []sim.Level) *Player {
+	p := &Player{Speed: 1, replay: r}
+	if r.Levels != nil {
+		p.campaign = r.Levels
+	} else if r.Campaign {
+		p.campaign = campaign
+	}
+	p.rewind()
+	p.rounds = []int{0}
+	for p.tick < len(r.Inputs) && !p.world.Over {
+		round := p.world.Round
+		p.step()
+		if p.world.Round != round {
+			p.rounds = append(p.rounds, p.tick)
+		}
+	}
+	p.rewind()
+	return p
+}
+
+func (p *Player) rewind() {
+	if p.replay.Start != nil {
+		p.world = sim.ResumeWorld(p.replay.Seed, *p.replay.Start, p.campaign)
+	} else {
+		p.world = sim.NewCampaignWorld(p.replay.Seed, p.campaign)
+	}
+	p.tick = 0
+}
+
+func (p *Player) step() {
+	if p.Done() {
+		return
+	}
+	p.world.Step(p.replay.Inputs[p.tick])
+	p.tick++
+}
+
+// World devolve o mundo no ponto atual da reprodução
+func (p *Player) World() *sim.World {
+	return p.world
+}
+
+// Tick devolve o tick atual e o total de ticks do replay
+func (p *Player) Tick() (int, int) {
+	return p.tick, len(p.replay.Inputs)
+}
+
+// Rounds devolve quantos tabuleiros a partida gravada teve
+func (p *Player) Rounds() int {
+	return len(p.rounds)
+}
+
+// Done diz se a reprodução chegou ao fim
+func (p *Player) Done() bool {
+	return p.tick >= len(p.replay.Inputs) || p.world.Over
+}
+
+// Update avança um quadro de reprodução, respeitando pausa e velocidade
+func (p *Player) Update() {
+	if p.Paused {
+		return
+	}
+	for i := 0; i < p.Speed; i++ {
+		p.step()
+	}
+}
+
+// StepFrame avança exatamente um tick, mesmo pausado
+func (p *Player) StepFrame() {
+	p.step()
+}
+
+// Seek volta ao começo do tabuleiro round (1 = o primeiro) e simula até lá
+func (p *Player) Seek(round int) {
+	round = max(1, min(round, len(p.rounds)))
+	p.rewind()
+	for p.tick < p.rounds[round-1] {
+		p.step()
+	}
+}
@@ -0,0 +1,291 @@
+// Package replay grava e reproduz partidas. Como o sim.World é determinístico,
+// uma partida inteira é só a seed mais a entrada de cada tick.
+//
+// O arquivo é texto, para poder ser anexado a relatos de bug:
+//
+//	tesourim-replay 3
+//	seed 1234
+//	campaign 1
+//	level {"Width":6,"Height":6,...}
+//	start {"Round":3,"CampaignStep":2,...}
+//	ticks 5400
+//	812 move=-1,0
+//	900 aim
+//	931 move=0,1 throw
+//	1204 flag=3,2
+//	1500 load=AEDAC-ABBFI-NB2VI-M7GEA
+//
+// Só os ticks com alguma entrada aparecem; os demais são entradas vazias. A
+// linha campaign diz se a partida começou pela campanha, e as linhas level
+// guardam os níveis dessa campanha em JSON. A linha start, opcional, é o
+// progresso de onde a partida continuou (um save ou um nível avulso). A versão
+// 1, ainda aceita por Read, não tem a linha campaign, e a 2 não tem as linhas
+// level e start; nelas a campanha é a do jogo.
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"example/tesourim/levelcode"
+	"example/tesourim/sim"
+)
+
+// Version é a versão do formato gravada por Write
+const Version = 3
+
+// MaxTicks é o maior número de ticks de um replay, três horas de jogo. Read
+// recusa replays maiores e Record para de gravar ao chegar nele.
+const MaxTicks = 3 * 60 * 60 * 60
+
+const magic = "tesourim-replay"
+
+var ErrFormat = errors.New("replay: arquivo inválido")
+
+// Replay é uma partida gravada
+type Replay struct {
+	Seed     int64
+	Campaign bool          // Se a partida começou pelos níveis da campanha
+	Levels   []sim.Level   // Níveis da campanha (nil nas versões antigas, que usam os do jogo)
+	Start    *sim.Progress // Progresso de onde a partida continuou (nil = do começo)
+	Inputs   []sim.Input   // Entrada de cada tick, na ordem
+}
+
+// New começa a gravação de uma partida com a seed dada
+func New(seed int64) *Replay {
+	return &Replay{Seed: seed}
+}
+
+// Record adiciona a entrada de mais um tick
+func (r *Replay) Record(in sim.Input) {
+	if len(r.Inputs) < MaxTicks {
+		r.Inputs = append(r.Inputs, in)
+	}
+}
+
+// Write grava o replay em w
+func (r *Replay) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s %d\n", magic, Version)
+	fmt.Fprintf(bw, "seed %d\n", r.Seed)
+	campaign := 0
+	if r.Campaign {
+		campaign = 1
+	}
+	fmt.Fprintf(bw, "campaign %d\n", campaign)
+	for _, l := range r.Levels {
+		data, err := json.Marshal(l)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, "level %s\n", data)
+	}
+	if r.Start != nil {
+		data, err := json.Marshal(r.Start)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, "start %s\n", data)
+	}
+	fmt.Fprintf(bw, "ticks %d\n", len(r.Inputs))
+	for tick, in := range r.Inputs {
+		if tokens := encodeInput(in); len(tokens) > 0 {
+			fmt.Fprintf(bw, "%d %s\n", tick, strings.Join(tokens, " "))
+		}
+	}
+	return bw.Flush()
+}
+
+// Save grava o replay no arquivo path
+func (r *Replay) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read lê um replay gravado por Write
+func Read(rd io.Reader) (*Replay, error) {
+	sc := bufio.NewScanner(rd)
+	sc.Buffer(nil, 1<<20) // As linhas level e start são maiores que o padrão
+	line := 0
+	scan := func() bool {
+		line++
+		return sc.Scan()
+	}
+	if !scan() {
+		return nil, ErrFormat
+	}
+	var version int
+	if _, err := fmt.Sscanf(sc.Text(), magic+" %d", &version); err != nil {
+		return nil, ErrFormat
+	}
+	if version < 1 || version > Version {
+		return nil, fmt.Errorf("%w: versão %d", ErrFormat, version)
+	}
+
+	r := &Replay{}
+	if !scan() {
+		return nil, ErrFormat
+	}
+	if _, err := fmt.Sscanf(sc.Text(), "seed %d", &r.Seed); err != nil {
+		return nil, ErrFormat
+	}
+	if version >= 2 {
+		if !scan() {
+			return nil, ErrFormat
+		}
+		var campaign int
+		if _, err := fmt.Sscanf(sc.Text(), "campaign %d", &campaign); err != nil {
+			return nil, ErrFormat
+		}
+		r.Campaign = campaign != 0
+	}
+	var ticks int
+	for {
+		if !scan() {
+			return nil, ErrFormat
+		}
+		name, value, _ := strings.Cut(sc.Text(), " ")
+		switch {
+		case name == "level" && version >= 3 && r.Start == nil:
+			var l sim.Level
+			if err := json.Unmarshal([]byte(value), &l); err != nil || !l.Valid() {
+				return nil, fmt.Errorf("%w: linha %d: nível inválido", ErrFormat, line)
+			}
+			r.Levels = append(r.Levels, l)
+			continue
+		case name == "start" && version >= 3 && r.Start == nil:
+			r.Start = &sim.Progress{}
+			if err := json.Unmarshal([]byte(value), r.Start); err != nil || !r.Start.Level.Valid() {
+				return nil, fmt.Errorf("%w: linha %d: progresso inválido", ErrFormat, line)
+			}
+			continue
+		case name == "ticks":
+			var err error
+			if ticks, err = strconv.Atoi(value); err != nil || ticks < 0 || ticks > MaxTicks {
+				return nil, fmt.Errorf("%w: linha %d", ErrFormat, line)
+			}
+		default:
+			return nil, fmt.Errorf("%w: linha %d", ErrFormat, line)
+		}
+		break
+	}
+	if len(r.Levels) > 0 && !r.Campaign {
+		return nil, fmt.Errorf("%w: níveis de campanha sem campanha", ErrFormat)
+	}
+	r.Inputs = make([]sim.Input, ticks)
+
+	for scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		tick, err := strconv.Atoi(fields[0])
+		if err != nil || tick < 0 || tick >= ticks {
+			return nil, fmt.Errorf("%w: linha %d", ErrFormat, line)
+		}
+		in, err := decodeInput(fields[1:])
+		if err != nil {
+			return nil, fmt.Errorf("%w: linha %d: %v", ErrFormat, line, err)
+		}
+		r.Inputs[tick] = in
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Load lê o replay do arquivo path
+func Load(path string) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+func encodeInput(in sim.Input) []string {
+	var tokens []string
+	if in.MoveX != 0 || in.MoveY != 0 {
+		tokens = append(tokens, fmt.Sprintf("move=%d,%d", in.MoveX, in.MoveY))
+	}
+	flags := []struct {
+		set  bool
+		name string
+	}{
+		{in.Aim, "aim"},
+		{in.Throw, "throw"},
+		{in.Reflect, "reflect"},
+		{in.Restart, "restart"},
+		{in.Advance, "advance"},
+		{in.Pause, "pause"},
+		{in.Torch, "torch"},
+	}
+	for _, f := range flags {
+		if f.set {
+			tokens = append(tokens, f.name)
+		}
+	}
+	if in.Flag {
+		tokens = append(tokens, fmt.Sprintf("flag=%d,%d", in.FlagX, in.FlagY))
+	}
+	if in.Load != nil {
+		tokens = append(tokens, "load="+levelcode.Encode(*in.Load))
+	}
+	return tokens
+}
+
+func decodeInput(tokens []string) (sim.Input, error) {
+	var in sim.Input
+	for _, token := range tokens {
+		name, value, _ := strings.Cut(token, "=")
+		switch name {
+		case "move":
+			if _, err := fmt.Sscanf(value, "%d,%d", &in.MoveX, &in.MoveY); err != nil {
+				return in, fmt.Errorf("movimento %q", value)
+			}
+		case "aim":
+			in.Aim = true
+		case "throw":
+			in.Throw = true
+		case "reflect":
+			in.Reflect = true
+		case "restart":
+			in.Restart = true
+		case "advance":
+			in.Advance = true
+		case "pause":
+			in.Pause = true
+		case "torch":
+			in.Torch = true
+		case "flag":
+			in.Flag = true
+			if _, err := fmt.Sscanf(value, "%d,%d", &in.FlagX, &in.FlagY); err != nil {
+				return in, fmt.Errorf("bandeira %q", value)
+			}
+		case "load":
+			board, err := levelcode.Decode(value)
+			if err != nil {
+				return in, err
+			}
+			in.Load = &board
+		default:
+			return in, fmt.Errorf("entrada desconhecida %q", name)
+		}
+	}
+	return in, nil
+}
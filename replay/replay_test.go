@@ -0,0 +1,177 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"example/tesourim/levelcode"
+	"example/tesourim/sim"
+)
+
+// TestRoundTrip grava um replay com Write e o lê de volta com Read
+func TestRoundTrip(t *testing.T) {
+	// O tabuleiro passa pelo código para sair como Read o devolve
+	board, err := levelcode.Decode(levelcode.Encode(sim.Board{Width: 6, Height: 6, Difficulty: 2, Treasure: 14, Traps: map[int]bool{1: true}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := New(1234)
+	want.Campaign = true
+	want.Levels = []sim.Level{sim.NewLevel(board)}
+	start := sim.LevelProgress(want.Levels[0])
+	start.Flags = map[int]bool{3: true}
+	want.Start = &start
+	for _, in := range []sim.Input{
+		{},
+		{MoveX: -1, MoveY: 1},
+		{Aim: true},
+		{MoveX: 1, Throw: true},
+		{},
+		{Reflect: true, Restart: true, Advance: true},
+		{Pause: true},
+		{Torch: true},
+		{Flag: true, FlagX: 3, FlagY: 2},
+		{Load: &board},
+		{},
+	} {
+		want.Record(in)
+	}
+
+	var buf bytes.Buffer
+	if err := want.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lido %+v, queria %+v", got, want)
+	}
+}
+
+// TestRecordStops confere que a gravação para em MaxTicks
+func TestRecordStops(t *testing.T) {
+	r := New(1)
+	for i := 0; i < MaxTicks+10; i++ {
+		r.Record(sim.Input{})
+	}
+	if len(r.Inputs) != MaxTicks {
+		t.Errorf("%d ticks gravados, queria %d", len(r.Inputs), MaxTicks)
+	}
+}
+
+// TestPlayerStart grava uma partida que continua de um progresso, com uma
+// campanha que o jogo não tem: o primeiro nível tem o tesouro logo acima da
+// entrada, e a reprodução tem que chegar no mesmo ponto do segundo
+func TestPlayerStart(t *testing.T) {
+	first := sim.NewLevel(sim.Board{Width: 2, Height: 2, Difficulty: 1, Treasure: 2})
+	first.Title, first.MemorizeTime = "Atalho", 60
+	board, err := sim.GenerateBoard(1, sim.SquareBoard(7), 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	campaign := []sim.Level{first, sim.NewLevel(board)}
+	start := sim.LevelProgress(first)
+	start.CampaignStep = 1
+
+	w := sim.ResumeWorld(5, start, campaign)
+	r := New(5)
+	r.Campaign, r.Levels, r.Start = true, campaign, &start
+	for tick := 0; tick < 1200 && !w.Over; tick++ {
+		in := sim.Input{MoveY: tick / 20 % 2, Advance: true}
+		r.Record(in)
+		w.Step(in)
+	}
+	if w.Level.Title != "" || w.CampaignStep != 2 {
+		t.Fatalf("a partida gravada não chegou ao segundo nível: %q, passo %d", w.Level.Title, w.CampaignStep)
+	}
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	back, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewPlayer(back, nil)
+	for !p.Done() {
+		p.StepFrame()
+	}
+	got := p.World()
+	if got.Round != w.Round || got.Lives != w.Lives || got.PlayerX != w.PlayerX || got.PlayerY != w.PlayerY ||
+		got.State != w.State || !reflect.DeepEqual(got.Level, w.Level) {
+		t.Errorf("reprodução na rodada %d, (%d, %d), estado %d; queria rodada %d, (%d, %d), estado %d",
+			got.Round, got.PlayerX, got.PlayerY, got.State, w.Round, w.PlayerX, w.PlayerY, w.State)
+	}
+}
+
+// TestOldVersions lê replays das versões 1, sem a linha campaign, e 2, sem
+// os níveis e o progresso
+func TestOldVersions(t *testing.T) {
+	tests := []struct {
+		file string
+		want *Replay
+	}{
+		{"tesourim-replay 1\nseed 42\nticks 3\n1 move=0,1\n", &Replay{Seed: 42, Inputs: []sim.Input{{}, {MoveY: 1}, {}}}},
+		{"tesourim-replay 2\nseed 42\ncampaign 1\nticks 2\n0 aim\n", &Replay{Seed: 42, Campaign: true, Inputs: []sim.Input{{Aim: true}, {}}}},
+	}
+	for _, tt := range tests {
+		r, err := Read(strings.NewReader(tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(r, tt.want) {
+			t.Errorf("lido %+v, queria %+v", r, tt.want)
+		}
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	l := sim.NewLevel(sim.Board{Width: 2, Height: 2, Difficulty: 1, Treasure: 3})
+	data, err := json.Marshal(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	level := string(data)
+	if data, err = json.Marshal(sim.LevelProgress(l)); err != nil {
+		t.Fatal(err)
+	}
+	start := string(data)
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"vazio", ""},
+		{"sem cabeçalho", "tesourim-replay 1\nseed 1\n"},
+		{"outro arquivo", "tesourim-save 1\nseed 1\nticks 1\n"},
+		{"versão nova", "tesourim-replay 9\nseed 1\nticks 1\n"},
+		{"seed", "tesourim-replay 1\nseed x\nticks 1\n"},
+		{"ticks negativos", "tesourim-replay 1\nseed 1\nticks -1\n"},
+		{"sem campanha", "tesourim-replay 2\nseed 1\nticks 1\n"},
+		{"campanha", "tesourim-replay 2\nseed 1\ncampaign x\nticks 1\n"},
+		{"ticks demais", fmt.Sprintf("tesourim-replay 1\nseed 1\nticks %d\n", MaxTicks+1)},
+		{"nível", "tesourim-replay 3\nseed 1\ncampaign 1\nlevel {\"Width\": 99}\nticks 1\n"},
+		{"nível não é JSON", "tesourim-replay 3\nseed 1\ncampaign 1\nlevel {\nticks 1\n"},
+		{"nível sem campanha", "tesourim-replay 3\nseed 1\ncampaign 0\nlevel " + level + "\nticks 1\n"},
+		{"nível na versão 2", "tesourim-replay 2\nseed 1\ncampaign 1\nlevel " + level + "\nticks 1\n"},
+		{"progresso", "tesourim-replay 3\nseed 1\ncampaign 0\nstart {\"Round\": 1}\nticks 1\n"},
+		{"dois progressos", "tesourim-replay 3\nseed 1\ncampaign 0\nstart " + start + "\nstart " + start + "\nticks 1\n"},
+		{"linha desconhecida", "tesourim-replay 3\nseed 1\ncampaign 0\nmusic 1\nticks 1\n"},
+		{"tick depois do fim", "tesourim-replay 1\nseed 1\nticks 3\n3 aim\n"},
+		{"entrada desconhecida", "tesourim-replay 1\nseed 1\nticks 3\n0 dance\n"},
+		{"movimento", "tesourim-replay 1\nseed 1\nticks 3\n0 move=x\n"},
+		{"bandeira", "tesourim-replay 1\nseed 1\nticks 3\n0 flag=3\n"},
+	}
+	for _, tt := range tests {
+		if _, err := Read(strings.NewReader(tt.file)); !errors.Is(err, ErrFormat) {
+			t.Errorf("%s: erro %v, queria ErrFormat", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package save guarda o progresso da partida em um arquivo JSON na pasta de
+// configuração do usuário, para continuar depois de fechar o jogo.
+package save
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"example/tesourim/sim"
+)
+
+// Version é a versão do formato gravada por Write. A versão 2 trocou o
+// tamanho do tabuleiro por largura, altura e células vazias, e a 3 guarda o
+// nível inteiro, com os limites e os inimigos, em vez de só o tabuleiro.
+const Version = 3
+
+var (
+	ErrVersion = errors.New("save: versão desconhecida")
+	ErrInvalid = errors.New("save: tabuleiro inválido")
+)
+
+// File é o conteúdo do arquivo de save
+type File struct {
+	Version  int          `json:"version"`
+	Seed     int64        `json:"seed"`
+	SavedAt  time.Time    `json:"saved_at"`
+	Progress sim.Progress `json:"progress"`
+}
+
+// Dir devolve a pasta onde o jogo guarda seus arquivos
+func Dir() (string, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(config, "tesourim"), nil
+}
+
+// Path devolve o caminho do arquivo de save
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "save.json"), nil
+}
+
+// Write salva o progresso da partida
+func Write(seed int64, p sim.Progress) error {
+	return writeJSON("save.json", File{Version: Version, Seed: seed, SavedAt: time.Now(), Progress: p})
+}
+
+// Read lê o progresso salvo. Devolve um erro que satisfaz
+// errors.Is(err, fs.ErrNotExist) quando não há save.
+func Read() (File, error) {
+	path, err := Path()
+	if err != nil {
+		return File{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, err
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return File{}, fmt.Errorf("save: %w", err)
+	}
+	if f.Version != Version {
+		return File{}, fmt.Errorf("%w: %d", ErrVersion, f.Version)
+	}
+	if !f.Progress.Level.Valid() {
+		return File{}, ErrInvalid
+	}
+	return f, nil
+}
+
+// Delete apaga o save, por exemplo quando o jogo é zerado
+func Delete() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package save
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"example/tesourim/sim"
+)
+
+// tempConfig troca a pasta de configuração do usuário por uma pasta
+// temporária, nos três sistemas
+func tempConfig(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", dir)
+}
+
+// testProgress é o progresso de uma partida já no meio do primeiro nível
+func testProgress() sim.Progress {
+	w := sim.NewWorld(7)
+	w.Step(sim.Input{Throw: true})
+	w.Step(sim.Input{MoveY: 1})
+	return w.Progress()
+}
+
+// TestRoundTrip salva um progresso com Write e o lê de volta com Read
+func TestRoundTrip(t *testing.T) {
+	tempConfig(t)
+	want := testProgress()
+	if err := Write(7, want); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Version != Version || f.Seed != 7 || f.SavedAt.IsZero() {
+		t.Errorf("versão %d, seed %d, salvo em %v", f.Version, f.Seed, f.SavedAt)
+	}
+	if !reflect.DeepEqual(f.Progress, want) {
+		t.Errorf("lido %+v, queria %+v", f.Progress, want)
+	}
+
+	if err := Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("depois de Delete: erro %v, queria fs.ErrNotExist", err)
+	}
+	if err := Delete(); err != nil {
+		t.Errorf("Delete sem save: %v", err)
+	}
+}
+
+// TestReadErrors confere que um save estragado é recusado em vez de
+// continuar uma partida impossível
+func TestReadErrors(t *testing.T) {
+	header := fmt.Sprintf(`{"version": %d, "seed": 7`, Version)
+	tests := []struct {
+		name string
+		data string
+		want error // nil = qualquer erro
+	}{
+		{"cortado", header + `, "progress": {"Round"`, nil},
+		{"não é JSON", "tesourim", nil},
+		{"versão antiga", `{"version": 1, "seed": 7}`, ErrVersion},
+		{"versão nova", `{"version": 99, "seed": 7}`, ErrVersion},
+		{"sem tabuleiro", header + `}`, ErrInvalid},
+		{"tesouro cercado", header + `, "progress": {"Level": {"Width": 6, "Height": 6, "Difficulty": 1, "Treasure": 14,
+			"Traps": {"7": true, "8": true, "9": true, "13": true, "15": true, "19": true, "20": true, "21": true}}}}`, ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempConfig(t)
+			path, err := Path()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err = Read()
+			if err == nil || (tt.want != nil && !errors.Is(err, tt.want)) {
+				t.Errorf("erro %v, queria %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestSettings confere que as preferências voltam como foram salvas e que,
+// sem arquivo ou com um arquivo estragado, valem as padrão
+func TestSettings(t *testing.T) {
+	tempConfig(t)
+	if got := ReadSettings(); got != DefaultSettings() {
+		t.Errorf("sem arquivo: %+v, queria as padrão", got)
+	}
+	want := Settings{Fullscreen: false, ShowControls: false}
+	if err := WriteSettings(want); err != nil {
+		t.Fatal(err)
+	}
+	if got := ReadSettings(); got != want {
+		t.Errorf("lido %+v, queria %+v", got, want)
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "settings.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := ReadSettings(); got != DefaultSettings() {
+		t.Errorf("arquivo estragado: %+v, queria as padrão", got)
+	}
+}
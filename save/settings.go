@@ -0,0 +1,66 @@
+package save
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Settings são as preferências escolhidas na tela de configurações
+type Settings struct {
+	Fullscreen   bool `json:"fullscreen"`
+	ShowControls bool `json:"show_controls"` // Mostra a linha de controles durante o jogo
+	CountFlags   bool `json:"count_flags"`   // Conta as bandeiras certas nas estatísticas
+}
+
+// DefaultSettings são as preferências usadas antes do jogador mudar alguma
+func DefaultSettings() Settings {
+	return Settings{Fullscreen: true, ShowControls: true, CountFlags: true}
+}
+
+// ReadSettings lê as preferências salvas, ou as padrão se não houver nenhuma
+func ReadSettings() Settings {
+	s := DefaultSettings()
+	readJSON("settings.json", &s)
+	return s
+}
+
+// WriteSettings salva as preferências
+func WriteSettings(s Settings) error {
+	return writeJSON("settings.json", s)
+}
+
+// readJSON lê um arquivo da pasta do jogo em v; arquivos ausentes ou
+// corrompidos deixam v como está
+func readJSON(name string, v any) {
+	dir, err := Dir()
+	if err != nil {
+		return
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return
+	}
+	json.Unmarshal(data, v)
+}
+
+// writeJSON grava v num arquivo da pasta do jogo, trocando o arquivo antigo
+// só depois da escrita terminar
+func writeJSON(name string, v any) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,23 @@
+package save
+
+// Stats são as estatísticas acumuladas entre todas as partidas
+type Stats struct {
+	GamesStarted int `json:"games_started"`
+	LevelsWon    int `json:"levels_won"`
+	Losses       int `json:"losses"`
+	BestLevel    int `json:"best_level"`    // Maior level alcançado (tamanho do grid - 5)
+	FlagsPlaced  int `json:"flags_placed"`  // Bandeiras nos níveis vencidos
+	FlagsCorrect int `json:"flags_correct"` // Dessas, as que estavam em armadilhas
+}
+
+// ReadStats lê as estatísticas salvas, ou zeradas se não houver nenhuma
+func ReadStats() Stats {
+	var s Stats
+	readJSON("stats.json", &s)
+	return s
+}
+
+// WriteStats salva as estatísticas
+func WriteStats(s Stats) error {
+	return writeJSON("stats.json", s)
+}
@@ -0,0 +1,628 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"log"
+	"strconv"
+	"time"
+
+	"example/tesourim/levelcode"
+	"example/tesourim/save"
+	"example/tesourim/sim"
+	"example/tesourim/utils"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+)
+
+// Medidas dos menus, em pixels
+const (
+	menuWidth   = 520
+	menuItemH   = 50
+	menuLineH   = 36
+	menuTitleH  = 70
+	overlayFade = 200
+)
+
+// menuItem é uma opção de um MenuScene
+type menuItem struct {
+	label    string
+	value    func() string   // Valor mostrado ao lado do rótulo (opcional)
+	action   func()          // ENTER, SPACE ou clique
+	change   func(delta int) // ←/→ para opções com valor (opcional)
+	disabled bool
+}
+
+// MenuScene é uma lista de opções navegável pelo teclado (setas/WASD, ENTER,
+// ESC) e pelo mouse (passar por cima seleciona, clique ativa)
+type MenuScene struct {
+	game     *Game
+	title    string
+	lines    []string // Texto informativo entre o título e as opções
+	items    []menuItem
+	selected int
+	overlay  bool         // Desenha por cima da cena de baixo em vez de limpar a tela
+	back     func()       // Ação do ESC (nil = ESC não faz nada)
+	backKeys []ebiten.Key // Outras teclas que também acionam back
+
+	sw, sh int // Tamanho da tela no último Draw, para o mouse
+}
+
+// moveSelection anda delta opções, pulando as desabilitadas
+func (m *MenuScene) moveSelection(delta int) {
+	for range m.items {
+		m.selected = (m.selected + delta + len(m.items)) % len(m.items)
+		if !m.items[m.selected].disabled {
+			return
+		}
+	}
+}
+
+// itemTop devolve o y do topo da opção i
+func (m *MenuScene) itemTop(i int) int {
+	block := menuTitleH + len(m.lines)*menuLineH + len(m.items)*menuItemH
+	return m.sh/2 - block/2 + menuTitleH + len(m.lines)*menuLineH + i*menuItemH
+}
+
+func (m *MenuScene) activate(item menuItem) {
+	if item.disabled {
+		return
+	}
+	if item.action != nil {
+		item.action()
+	} else if item.change != nil {
+		item.change(1)
+	}
+}
+
+func (m *MenuScene) Update() error {
+	if len(m.items) == 0 {
+		return nil
+	}
+	if m.items[m.selected].disabled {
+		m.moveSelection(1)
+	}
+
+	// Mouse: passar por cima seleciona, clique ativa
+	mx, my := ebiten.CursorPosition()
+	left := m.sw/2 - menuWidth/2
+	for i, item := range m.items {
+		top := m.itemTop(i)
+		if item.disabled || mx < left || mx >= left+menuWidth || my < top || my >= top+menuItemH {
+			continue
+		}
+		m.selected = i
+		if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+			m.activate(item)
+			return nil
+		}
+	}
+
+	if m.back != nil {
+		for _, key := range m.backKeys {
+			if inpututil.IsKeyJustPressed(key) {
+				m.back()
+				return nil
+			}
+		}
+	}
+
+	item := m.items[m.selected]
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		if m.back != nil {
+			m.back()
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyUp) || inpututil.IsKeyJustPressed(ebiten.KeyW):
+		m.moveSelection(-1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyDown) || inpututil.IsKeyJustPressed(ebiten.KeyS):
+		m.moveSelection(1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyLeft) || inpututil.IsKeyJustPressed(ebiten.KeyA):
+		if item.change != nil {
+			item.change(-1)
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyRight) || inpututil.IsKeyJustPressed(ebiten.KeyD):
+		if item.change != nil {
+			item.change(1)
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeySpace):
+		m.activate(item)
+	}
+	return nil
+}
+
+func (m *MenuScene) Draw(screen *ebiten.Image) {
+	m.sw, m.sh = screen.Bounds().Dx(), screen.Bounds().Dy()
+	if m.overlay {
+		ebitenutil.DrawRect(screen, 0, 0, float64(m.sw), float64(m.sh), color.RGBA{0, 0, 0, overlayFade})
+	} else {
+		screen.Fill(color.Black)
+	}
+
+	top := m.itemTop(0) - len(m.lines)*menuLineH - menuTitleH
+	drawCentered(screen, m.title, mplusNormalFont, m.sw/2, top+40, color.RGBA{255, 215, 0, 255})
+	for i, line := range m.lines {
+		drawCentered(screen, line, mplusBoldFont, m.sw/2, top+menuTitleH+i*menuLineH+26, color.White)
+	}
+
+	left := m.sw/2 - menuWidth/2
+	for i, item := range m.items {
+		y := m.itemTop(i)
+		if i == m.selected && !item.disabled {
+			ebitenutil.DrawRect(screen, float64(left), float64(y+4), menuWidth, menuItemH-8, color.RGBA{60, 60, 120, 255})
+		}
+		label := item.label
+		if item.value != nil {
+			label = fmt.Sprintf("%s:  < %s >", label, item.value())
+		}
+		clr := color.Color(color.White)
+		if item.disabled {
+			clr = color.RGBA{110, 110, 110, 255}
+		}
+		drawCentered(screen, label, mplusNormalFont, m.sw/2, y+35, clr)
+	}
+}
+
+// drawCentered desenha str centralizado horizontalmente em x
+func drawCentered(screen *ebiten.Image, str string, face font.Face, x, y int, clr color.Color) {
+	bounds := font.MeasureString(face, str)
+	text.Draw(screen, str, face, x-bounds.Round()/2, y, clr)
+}
+
+// TitleScene é a primeira tela do jogo
+type TitleScene struct {
+	game *Game
+}
+
+func newTitleScene(game *Game) *TitleScene {
+	return &TitleScene{game: game}
+}
+
+func (t *TitleScene) Update() error {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		return ebiten.Termination
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeySpace) ||
+		inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		t.game.Switch(newMainMenu(t.game))
+	}
+	return nil
+}
+
+func (t *TitleScene) Draw(screen *ebiten.Image) {
+	sw, sh := screen.Bounds().Dx(), screen.Bounds().Dy()
+	screen.Fill(color.Black)
+	drawCentered(screen, "Tesourim", mplusNormalFont, sw/2, sh/2-40, color.RGBA{255, 215, 0, 255})
+	drawCentered(screen, "Memorize as armadilhas, ache o tesouro", mplusBoldFont, sw/2, sh/2+10, color.White)
+	drawCentered(screen, "Pressione ENTER ou clique para começar", basicfont.Face7x13, sw/2, sh/2+80, color.White)
+}
+
+// newMainMenu monta o menu principal
+func newMainMenu(game *Game) *MenuScene {
+	m := &MenuScene{game: game, title: "Tesourim"}
+
+	continueItem := menuItem{label: "Continuar", disabled: true}
+	if f, err := save.Read(); err == nil {
+		continueItem = menuItem{
+			label: fmt.Sprintf("Continuar (level %d)", f.Progress.Level.Size()-5),
+			action: func() {
+				game.resumeGame(f.Seed, f.Progress)
+			},
+		}
+	}
+
+	m.items = []menuItem{
+		{label: "Novo jogo", action: func() {
+			game.startGame(sim.NewCampaignWorld(game.newSeed(), game.campaign))
+		}},
+		continueItem,
+		{label: "Desafio diário", action: func() {
+			game.startGame(sim.NewWorld(dailySeed(time.Now())))
+		}},
+		{label: "Tabuleiro personalizado", action: func() {
+			game.Push(newCustomMenu(game))
+		}},
+		{label: "Editor de níveis", action: func() {
+			game.Push(newEditorScene(game))
+		}},
+		{label: "Configurações", action: func() {
+			game.Push(newSettingsMenu(game))
+		}},
+		{label: "Estatísticas", action: func() {
+			game.Push(newStatsMenu(game))
+		}},
+		{label: "Sair", action: func() {
+			game.Push(newConfirm(game, "Sair do jogo?", game.quit))
+		}},
+	}
+	return m
+}
+
+// dailySeed é a mesma para todos os jogadores no mesmo dia (ex.: 20261016)
+func dailySeed(now time.Time) int64 {
+	seed, _ := strconv.ParseInt(now.Format("20060102"), 10, 64)
+	return seed
+}
+
+// quit salva a partida aberta e fecha o jogo
+func (g *Game) quit() {
+	if play := g.play(); play != nil {
+		play.saveProgress()
+	}
+	g.scenes = nil
+}
+
+// newConfirm pergunta algo ao jogador por cima da cena atual
+func newConfirm(game *Game, question string, onYes func()) *MenuScene {
+	return &MenuScene{
+		game:    game,
+		title:   question,
+		overlay: true,
+		back:    game.Pop,
+		items: []menuItem{
+			{label: "Sim", action: onYes},
+			{label: "Não", action: game.Pop},
+		},
+		selected: 1,
+	}
+}
+
+// newPauseMenu fica por cima da partida enquanto o sim.World estiver pausado.
+// Continuar (ou ESC/P de novo) tira o mundo da pausa.
+func newPauseMenu(game *Game, play *PlayScene) *MenuScene {
+	resume := func() {
+		play.resume()
+		game.Pop()
+	}
+	m := &MenuScene{
+		game:     game,
+		title:    "Pausado",
+		overlay:  true,
+		back:     resume,
+		backKeys: []ebiten.Key{ebiten.KeyP},
+		items: []menuItem{
+			{label: "Continuar", action: resume},
+			{label: "Reiniciar nível", action: func() {
+				game.Push(newConfirm(game, "Reiniciar o nível?", func() {
+					play.restart()
+					game.Pop()
+					game.Pop()
+				}))
+			}},
+			{label: "Carregar nível por código", action: func() {
+				game.Pop()
+				game.Push(newCodeScene(game, func(b sim.Board) {
+					play.load(b)
+					game.Pop()
+				}))
+			}},
+			{label: "Menu principal", action: func() {
+				game.Push(newConfirm(game, "Voltar ao menu? O progresso fica salvo.", func() {
+					play.saveProgress()
+					game.Switch(newMainMenu(game))
+				}))
+			}},
+			{label: "Sair", action: func() {
+				game.Push(newConfirm(game, "Sair do jogo? O progresso fica salvo.", game.quit))
+			}},
+		},
+	}
+	if play.playtest {
+		// Tira o menu de pausa e a partida de teste
+		back := menuItem{label: "Voltar ao editor", action: func() {
+			game.Pop()
+			game.Pop()
+		}}
+		m.items = append(m.items[:1], append([]menuItem{back}, m.items[1:]...)...)
+	}
+	return m
+}
+
+// boardShapes são os formatos oferecidos no tabuleiro personalizado
+var boardShapes = []struct {
+	name  string
+	shape func(width, height int) utils.Shape
+}{
+	{"Retângulo", func(width, height int) utils.Shape { return utils.Shape{Width: width, Height: height} }},
+	{"L", utils.LShape},
+	{"Anel", utils.RingShape},
+	{"Ilhas", utils.IslandsShape},
+}
+
+// movementNames são os nomes das regras de movimento nos menus
+var movementNames = [utils.Movements]string{
+	utils.King:       "Rei",
+	utils.Orthogonal: "Torre",
+	utils.Diagonal:   "Bispo",
+	utils.Knight:     "Cavalo",
+}
+
+// wallAmounts são as quantidades de paredes do tabuleiro personalizado, como
+// fração do número de células
+var wallAmounts = []struct {
+	name    string
+	divisor int
+}{
+	{"Nenhuma", 0},
+	{"Poucas", 6},
+	{"Muitas", 3},
+}
+
+// newCustomMenu escolhe formato, grade, movimento, paredes, dicas, escuro, tamanho e dificuldade de um tabuleiro avulso
+func newCustomMenu(game *Game) *MenuScene {
+	width, height, shape, dificulty := 6, 6, 0, 1
+	hex, movement := false, utils.King
+	walls, hideWalls, hints, dark := 0, false, false, false
+	start := func(b sim.Board, seed int64) {
+		play := game.startGame(sim.NewWorld(seed))
+		play.load(b)
+	}
+	clamp := func(v int) int { return min(max(v, 4), sim.MaxGridSize) }
+	var menu *MenuScene
+	menu = &MenuScene{
+		game:  game,
+		title: "Tabuleiro personalizado",
+		back:  game.Pop,
+		items: []menuItem{
+			{
+				label:  "Formato",
+				value:  func() string { return boardShapes[shape].name },
+				change: func(d int) { shape = (shape + d + len(boardShapes)) % len(boardShapes) },
+			},
+			{
+				label: "Grade",
+				value: func() string {
+					if hex {
+						return "Hexagonal"
+					}
+					return "Quadrada"
+				},
+				change: func(int) { hex = !hex },
+			},
+			{
+				label: "Movimento",
+				value: func() string {
+					if hex {
+						return "Hexágonos"
+					}
+					return movementNames[movement]
+				},
+				change: func(d int) {
+					movement = (movement + utils.Movement(d) + utils.Movements) % utils.Movements
+				},
+			},
+			{
+				label:  "Paredes",
+				value:  func() string { return wallAmounts[walls].name },
+				change: func(d int) { walls = (walls + d + len(wallAmounts)) % len(wallAmounts) },
+			},
+			{
+				label: "Paredes escondidas",
+				value: func() string {
+					if hideWalls {
+						return "Sim"
+					}
+					return "Não"
+				},
+				change: func(int) { hideWalls = !hideWalls },
+			},
+			{
+				label: "Dicas",
+				value: func() string {
+					if hints {
+						return "Sim"
+					}
+					return "Não"
+				},
+				change: func(int) { hints = !hints },
+			},
+			{
+				label: "Escuro",
+				value: func() string {
+					if dark {
+						return "Sim"
+					}
+					return "Não"
+				},
+				change: func(int) { dark = !dark },
+			},
+			{
+				label:  "Largura",
+				value:  func() string { return strconv.Itoa(width) },
+				change: func(d int) { width = clamp(width + d) },
+			},
+			{
+				label:  "Altura",
+				value:  func() string { return strconv.Itoa(height) },
+				change: func(d int) { height = clamp(height + d) },
+			},
+			{
+				label:  "Dificuldade",
+				value:  func() string { return strconv.Itoa(dificulty) },
+				change: func(d int) { dificulty = min(max(dificulty+d, 1), 3) },
+			},
+			{label: "Jogar", action: func() {
+				seed := game.newSeed()
+				s := boardShapes[shape].shape(width, height)
+				b := sim.Board{Width: s.Width, Height: s.Height, Void: s.Void, Hex: hex, Hints: hints, Dark: dark}
+				if !hex {
+					b.Movement = movement
+				}
+				count := 0
+				if amount := wallAmounts[walls]; amount.divisor > 0 {
+					count = len(s.Nodes()) / amount.divisor
+					b.HideWalls = hideWalls
+				}
+				b, err := sim.GenerateBoard(seed, b, dificulty, count)
+				if errors.Is(err, utils.ErrNoEntrance) {
+					menu.lines = []string{"Esse formato não tem por onde entrar"}
+					return
+				}
+				if err != nil {
+					menu.lines = []string{"Esse formato não tem caminho até o tesouro"}
+					return
+				}
+				menu.lines = nil
+				start(b, seed)
+			}},
+			{label: "Carregar código", action: func() {
+				game.Push(newCodeScene(game, func(b sim.Board) {
+					start(b, game.newSeed())
+				}))
+			}},
+			{label: "Voltar", action: game.Pop},
+		},
+	}
+	return menu
+}
+
+// newSettingsMenu mostra as preferências, que são salvas a cada mudança
+func newSettingsMenu(game *Game) *MenuScene {
+	yesNo := func(v bool) string {
+		if v {
+			return "Sim"
+		}
+		return "Não"
+	}
+	apply := func() {
+		ebiten.SetFullscreen(game.settings.Fullscreen)
+		if err := save.WriteSettings(game.settings); err != nil {
+			log.Println(err)
+		}
+	}
+	return &MenuScene{
+		game:  game,
+		title: "Configurações",
+		back:  game.Pop,
+		items: []menuItem{
+			{
+				label:  "Tela cheia",
+				value:  func() string { return yesNo(game.settings.Fullscreen) },
+				change: func(int) { game.settings.Fullscreen = !game.settings.Fullscreen; apply() },
+			},
+			{
+				label:  "Mostrar controles",
+				value:  func() string { return yesNo(game.settings.ShowControls) },
+				change: func(int) { game.settings.ShowControls = !game.settings.ShowControls; apply() },
+			},
+			{
+				label:  "Contar bandeiras",
+				value:  func() string { return yesNo(game.settings.CountFlags) },
+				change: func(int) { game.settings.CountFlags = !game.settings.CountFlags; apply() },
+			},
+			{label: "Voltar", action: game.Pop},
+		},
+	}
+}
+
+// newStatsMenu mostra as estatísticas acumuladas
+func newStatsMenu(game *Game) *MenuScene {
+	s := game.stats
+	return &MenuScene{
+		game:  game,
+		title: "Estatísticas",
+		back:  game.Pop,
+		lines: []string{
+			fmt.Sprintf("Partidas iniciadas: %d", s.GamesStarted),
+			fmt.Sprintf("Níveis vencidos: %d", s.LevelsWon),
+			fmt.Sprintf("Derrotas: %d", s.Losses),
+			fmt.Sprintf("Melhor level: %d", s.BestLevel),
+			fmt.Sprintf("Bandeiras certas: %d de %d", s.FlagsCorrect, s.FlagsPlaced),
+		},
+		items: []menuItem{{label: "Voltar", action: game.Pop}},
+	}
+}
+
+// CodeScene é a tela onde o jogador digita um código de nível compartilhado
+type CodeScene struct {
+	game   *Game
+	input  string
+	err    string
+	onLoad func(sim.Board)        // Chamado com o tabuleiro quando o código é válido
+	paste  <-chan clipboardResult // Leitura da área de transferência em andamento (nil se não houver)
+}
+
+func newCodeScene(game *Game, onLoad func(sim.Board)) *CodeScene {
+	return &CodeScene{game: game, onLoad: onLoad}
+}
+
+func (c *CodeScene) Update() error {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		c.game.Pop()
+		return nil
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(c.input) > 0 {
+		c.input = c.input[:len(c.input)-1]
+	}
+	c.typeText(string(ebiten.AppendInputChars(nil)))
+	ctrl := ebiten.IsKeyPressed(ebiten.KeyControl) || ebiten.IsKeyPressed(ebiten.KeyMeta)
+	if ctrl && inpututil.IsKeyJustPressed(ebiten.KeyV) && c.paste == nil {
+		c.paste = readClipboard()
+		c.err = ""
+	}
+	select {
+	case r := <-c.paste:
+		c.paste = nil
+		if r.err != nil {
+			c.err = "Não deu para colar: " + r.err.Error()
+			return nil
+		}
+		c.typeText(r.text)
+	default:
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		board, err := levelcode.Decode(c.input)
+		if err != nil {
+			c.err = err.Error()
+			return nil
+		}
+		c.onLoad(board)
+	}
+	return nil
+}
+
+// typeText acrescenta ao código o texto digitado ou colado, sem passar do
+// maior código possível. Quebras de linha e outros caracteres de controle
+// ficam de fora.
+func (c *CodeScene) typeText(text string) {
+	for _, r := range text {
+		if r >= ' ' && r < 128 && len(c.input) < levelcode.MaxLen {
+			c.input += string(r)
+		}
+	}
+}
+
+// codeLineLen é quantos caracteres do código cabem numa linha da tela: cinco
+// grupos de 5 letras e os hífens entre eles
+const codeLineLen = 29
+
+func (c *CodeScene) Draw(screen *ebiten.Image) {
+	sw, sh := screen.Bounds().Dx(), screen.Bounds().Dy()
+	ebitenutil.DrawRect(screen, 0, 0, float64(sw), float64(sh), color.RGBA{0, 0, 0, 220})
+
+	// O código longo é quebrado em linhas
+	input := c.input + "_"
+	var lines []string
+	for len(input) > codeLineLen {
+		lines = append(lines, input[:codeLineLen])
+		input = input[codeLineLen:]
+	}
+	lines = append(lines, input)
+	lines = append(append([]string{"Digite o código do nível"}, lines...),
+		"ENTER carrega | Ctrl+V cola | ESC cancela")
+	y := sh/2 - 40 - 15*(len(lines)-3)
+	for i, line := range lines {
+		drawCentered(screen, line, mplusNormalFont, sw/2, y+i*30, color.White)
+	}
+	if c.paste != nil {
+		drawCentered(screen, "colando…", mplusNormalFont, sw/2, y+len(lines)*30+40, color.White)
+	} else if c.err != "" {
+		drawCentered(screen, c.err, mplusNormalFont, sw/2, y+len(lines)*30+40, color.RGBA{255, 0, 0, 255})
+	}
+}
@@ -0,0 +1,286 @@
+package sim
+
+import (
+	"math"
+
+	"example/tesourim/utils"
+)
+
+// EnemyBehavior decide como um inimigo anda e quando atira. Cada inimigo tem
+// a sua, já que os comportamentos guardam estado. Tudo acontece ao longo do
+// lado do inimigo: player é a posição do jogador projetada nesse lado, e o
+// deslocamento devolvido por Move é somado a Enemy.Pos.
+type EnemyBehavior interface {
+	// Move devolve o deslocamento do inimigo neste tick. Em modo killer o
+	// inimigo persegue o jogador: Move devolve e.chase, que deve ser chamado
+	// uma única vez por tick, já que cada chamada avança o PID.
+	Move(w *World, e *Enemy, player float64) float64
+	// Fire diz se o inimigo tenta atirar, quando a arma já recarregou
+	Fire(w *World, e *Enemy, player float64) bool
+	// Reflected avisa que o jogador refletiu o projétil b do inimigo
+	Reflected(w *World, e *Enemy, b *Bullet)
+}
+
+// BehaviorKind é um dos comportamentos de inimigo. O zero é o Wanderer, o
+// comportamento original, então níveis sem Level.Behaviors continuam iguais.
+type BehaviorKind int
+
+const (
+	Wanderer  BehaviorKind = iota // Alterna, a cada 6 segundos, entre andar a esmo e perseguir
+	Patroller                     // Vai e volta de ponta a ponta
+	Tracker                       // Persegue o jogador o tempo todo
+	Ambusher                      // Espera parado e avança de repente
+	Predictor                     // Mira onde o jogador vai estar
+	Coward                        // Anda a esmo e foge dos projéteis refletidos
+)
+
+// BehaviorKinds é o número de comportamentos de inimigo
+const BehaviorKinds = 6
+
+// behaviorInfos é o registro dos comportamentos: nome, construtor e a arma
+// que o inimigo usa quando o nível não escolhe outra
+var behaviorInfos = [BehaviorKinds]struct {
+	name   string
+	new    func() EnemyBehavior
+	weapon WeaponKind
+}{
+	Wanderer:  {"Andarilho", func() EnemyBehavior { return &wanderer{} }, Straight},
+	Patroller: {"Patrulheiro", func() EnemyBehavior { return &patroller{dir: 1} }, Triple},
+	Tracker:   {"Caçador", func() EnemyBehavior { return tracker{} }, Aimed},
+	Ambusher:  {"Emboscador", func() EnemyBehavior { return &ambusher{} }, Fan},
+	Predictor: {"Previsor", func() EnemyBehavior { return &predictor{} }, Straight},
+	Coward:    {"Covarde", func() EnemyBehavior { return &coward{} }, Homing},
+}
+
+// Name devolve o nome do comportamento k
+func (k BehaviorKind) Name() string {
+	if !k.Valid() {
+		k = Wanderer
+	}
+	return behaviorInfos[k].name
+}
+
+// Valid diz se k é um dos comportamentos conhecidos
+func (k BehaviorKind) Valid() bool {
+	return k >= 0 && k < BehaviorKinds
+}
+
+// New cria o comportamento k para um inimigo
+func (k BehaviorKind) New() EnemyBehavior {
+	if !k.Valid() {
+		k = Wanderer
+	}
+	return behaviorInfos[k].new()
+}
+
+// Weapon devolve a arma padrão do comportamento k
+func (k BehaviorKind) Weapon() WeaponKind {
+	if !k.Valid() {
+		k = Wanderer
+	}
+	return behaviorInfos[k].weapon
+}
+
+// aligned diz se o inimigo está alinhado com o ponto x do seu lado, o
+// critério de tiro padrão
+func aligned(e *Enemy, x float64) bool {
+	return math.Abs(x-e.Pos) < 0.5
+}
+
+// randomTarget sorteia um novo ponto de destino no lado do inimigo
+func randomTarget(w *World, e *Enemy) float64 {
+	return utils.RandomFloat64(w.rng) * e.span(w.Level.Board)
+}
+
+// wander anda em direção a e.target e sorteia outro destino ao chegar
+func wander(w *World, e *Enemy) float64 {
+	dx := utils.RandomMoves(w.rng, e.Pos, e.target, int(e.span(w.Level.Board))+1)
+	if math.Abs(e.Pos+dx-e.target) < 0.1 {
+		e.target = randomTarget(w, e)
+	}
+	return dx
+}
+
+// wanderer é o comportamento original: a cada 6 segundos tira cara ou coroa
+// para entrar ou sair do modo killer e, fora dele, anda a esmo
+type wanderer struct {
+	changeModeTimer int
+}
+
+func (b *wanderer) Move(w *World, e *Enemy, player float64) float64 {
+	if b.changeModeTimer == 0 {
+		b.changeModeTimer = 6 * 60
+		w.setKillerMode(e, utils.CaraOuCoroa(w.rng))
+		if !e.KillerMode {
+			e.target = randomTarget(w, e)
+		}
+	} else {
+		b.changeModeTimer--
+	}
+	if e.KillerMode {
+		return e.chase(player)
+	}
+	return wander(w, e)
+}
+
+func (b *wanderer) Fire(w *World, e *Enemy, player float64) bool {
+	return aligned(e, player)
+}
+
+func (b *wanderer) Reflected(w *World, e *Enemy, bullet *Bullet) {}
+
+// patrolSpeed é a velocidade do Patroller, em células por tick
+const patrolSpeed = 0.04
+
+// patroller vai e volta de uma ponta à outra e dá meia-volta quando um tiro
+// seu é refletido
+type patroller struct {
+	dir float64
+}
+
+func (b *patroller) Move(w *World, e *Enemy, player float64) float64 {
+	if e.KillerMode {
+		return e.chase(player)
+	}
+	if next := e.Pos + b.dir*patrolSpeed; next < 0 || next > e.span(w.Level.Board) {
+		b.dir = -b.dir
+	}
+	return b.dir * patrolSpeed
+}
+
+func (b *patroller) Fire(w *World, e *Enemy, player float64) bool {
+	return aligned(e, player)
+}
+
+func (b *patroller) Reflected(w *World, e *Enemy, bullet *Bullet) {
+	b.dir = -b.dir
+}
+
+// tracker persegue o jogador o tempo todo, sem contar como killer; em modo
+// killer, nada muda
+type tracker struct{}
+
+func (tracker) Move(w *World, e *Enemy, player float64) float64 {
+	return e.chase(player)
+}
+
+func (tracker) Fire(w *World, e *Enemy, player float64) bool {
+	return aligned(e, player)
+}
+
+func (tracker) Reflected(w *World, e *Enemy, bullet *Bullet) {}
+
+// Tempos e velocidade do Ambusher
+const (
+	ambushWait   = 3 * 60 // Ticks parado antes de avançar
+	ambushSprint = 60     // Ticks avançando
+	sprintSpeed  = 0.2    // Células por tick durante o avanço
+)
+
+// ambusher espera parado e então corre até a coluna do jogador
+type ambusher struct {
+	timer     int
+	sprinting bool
+}
+
+func (b *ambusher) Move(w *World, e *Enemy, player float64) float64 {
+	if e.KillerMode {
+		return e.chase(player)
+	}
+	b.timer++
+	if !b.sprinting {
+		if b.timer >= ambushWait {
+			b.sprinting, b.timer = true, 0
+		}
+		return 0
+	}
+	if math.Abs(player-e.Pos) < sprintSpeed {
+		b.sprinting, b.timer = false, 0
+		return player - e.Pos
+	}
+	if b.timer >= ambushSprint {
+		b.sprinting, b.timer = false, 0
+		return 0
+	}
+	return math.Copysign(sprintSpeed, player-e.Pos)
+}
+
+func (b *ambusher) Fire(w *World, e *Enemy, player float64) bool {
+	return aligned(e, player)
+}
+
+func (b *ambusher) Reflected(w *World, e *Enemy, bullet *Bullet) {}
+
+// predictor estima a velocidade do jogador e se alinha com onde ele vai
+// estar quando o projétil chegar, também em modo killer
+type predictor struct {
+	lastX    float64
+	velocity float64 // Média móvel do deslocamento do jogador por tick
+	started  bool
+}
+
+// lead devolve o ponto onde o jogador deve estar quando um projétil da arma
+// do inimigo, atirado agora, chegar nele
+func (b *predictor) lead(w *World, e *Enemy, player float64) float64 {
+	_, dist := e.Track(w)
+	ticks := dist / e.Weapon.Speed
+	return min(max(player+b.velocity*ticks, 0), e.span(w.Level.Board))
+}
+
+func (b *predictor) Move(w *World, e *Enemy, player float64) float64 {
+	if b.started {
+		b.velocity = 0.95*b.velocity + 0.05*(player-b.lastX)
+	}
+	b.lastX, b.started = player, true
+	return e.chase(b.lead(w, e, player))
+}
+
+func (b *predictor) Fire(w *World, e *Enemy, player float64) bool {
+	return aligned(e, b.lead(w, e, player))
+}
+
+func (b *predictor) Reflected(w *World, e *Enemy, bullet *Bullet) {}
+
+// Fuga do Coward
+const (
+	fleeTicks = 90   // Quanto tempo foge depois de um reflexo
+	fleeSpeed = 0.12 // Células por tick durante a fuga
+)
+
+// coward anda a esmo e, quando um tiro seu é refletido, foge para longe
+// dele sem atirar
+type coward struct {
+	fleeing int
+	fleeDir float64
+}
+
+func (b *coward) Move(w *World, e *Enemy, player float64) float64 {
+	if e.KillerMode {
+		return e.chase(player)
+	}
+	if b.fleeing > 0 {
+		b.fleeing--
+		if b.fleeing == 0 {
+			e.target = randomTarget(w, e)
+		}
+		return b.fleeDir * fleeSpeed
+	}
+	return wander(w, e)
+}
+
+func (b *coward) Fire(w *World, e *Enemy, player float64) bool {
+	return b.fleeing == 0 && aligned(e, player)
+}
+
+func (b *coward) Reflected(w *World, e *Enemy, bullet *Bullet) {
+	b.fleeing = fleeTicks
+	b.fleeDir = -1
+	// O reflexo volta pela posição do projétil ao longo do lado
+	along := bullet.X
+	if e.Side == Left || e.Side == Right {
+		along = bullet.Y
+	}
+	if along < e.Pos || (along == e.Pos && e.Pos < e.span(w.Level.Board)/2) {
+		b.fleeDir = 1
+	}
+}
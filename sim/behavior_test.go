@@ -0,0 +1,120 @@
+package sim
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// TestLevelBehavior confere que a lista de comportamentos do nível se
+// repete e que, vazia, todos são Wanderer
+func TestLevelBehavior(t *testing.T) {
+	l := Level{Behaviors: []BehaviorKind{Tracker, Coward}}
+	for i, want := range []BehaviorKind{Tracker, Coward, Tracker, Coward} {
+		if got := l.Behavior(i); got != want {
+			t.Errorf("inimigo %d: %s, queria %s", i, got.Name(), want.Name())
+		}
+	}
+	if got := (Level{}).Behavior(3); got != Wanderer {
+		t.Errorf("sem lista: %s, queria Andarilho", got.Name())
+	}
+}
+
+// TestPatroller anda com o Patroller de uma ponta à outra do tabuleiro de
+// testWorld e confere que ele dá meia-volta sem sair da linha
+func TestPatroller(t *testing.T) {
+	w := testWorld(2)
+	e := &Enemy{}
+	b := Patroller.New()
+	turns, last := 0, 1.0
+	for range 400 {
+		dx := b.Move(w, e, 0)
+		if dx*last < 0 {
+			turns++
+		}
+		e.Pos += dx
+		last = dx
+		if e.Pos < 0 || e.Pos > 5 {
+			t.Fatalf("patrulheiro saiu da linha: x = %.2f", e.Pos)
+		}
+	}
+	if turns != 3 {
+		t.Errorf("%d meias-voltas em 400 ticks, queria 3", turns)
+	}
+
+	// Um reflexo o faz voltar na hora
+	b.Reflected(w, e, &Bullet{})
+	if dx := b.Move(w, e, 0); dx*last >= 0 {
+		t.Errorf("depois do reflexo anda %.2f, na mesma direção de antes", dx)
+	}
+}
+
+// TestKillerModeUpdatesPIDOnce confere que, em modo killer, cada tick avança
+// o PID do inimigo uma única vez, atrás do jogador
+func TestKillerModeUpdatesPIDOnce(t *testing.T) {
+	for k := BehaviorKind(0); k < BehaviorKinds; k++ {
+		if k == Predictor {
+			continue // Persegue o ponto previsto, não o jogador
+		}
+		t.Run(k.Name(), func(t *testing.T) {
+			w := NewWorld(1)
+			l := NewLevel(generate(t, 1, SquareBoard(7), 2))
+			l.Enemies = 1
+			l.Behaviors = []BehaviorKind{k}
+			w.LoadLevel(l)
+			w.State = Playing
+			w.PlayerX, w.PlayerY = 5, 3
+			e := w.Enemies[0]
+			if b, ok := e.Behavior.(*wanderer); ok {
+				b.changeModeTimer = 60 // Não sai do modo killer no meio do teste
+			}
+			w.triggerAlarm(w.Level.Node(0, 0))
+
+			want := *e.pid
+			for tick := 0; tick < 10; tick++ {
+				player, _ := e.Track(w)
+				want.Update(player, e.Pos)
+				e.update(w)
+				if !reflect.DeepEqual(*e.pid, want) {
+					t.Fatalf("tick %d: PID %+v, queria %+v", tick, *e.pid, want)
+				}
+			}
+		})
+	}
+}
+
+// TestPredictorLead confere que o Predictor mira mais à frente com uma arma
+// mais lenta, contando o tempo que o projétil dela leva para chegar
+func TestPredictorLead(t *testing.T) {
+	w := testWorld(2)
+	e := &Enemy{}
+	b := &predictor{velocity: 0.01, started: true}
+	_, dist := e.Track(w)
+	for _, k := range []WeaponKind{Straight, Aimed, Homing} {
+		e.Weapon = k.Weapon()
+		want := min(0.01*dist/e.Weapon.Speed, e.span(w.Level.Board))
+		if got := b.lead(w, e, 0); math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s: mira em %.3f, queria %.3f", k.Name(), got, want)
+		}
+	}
+}
+
+// TestAmbusherSprint confere que o Ambusher nunca anda mais que sprintSpeed
+// num tick, mesmo com o jogador longe, e que chega nele depois de alguns
+// avanços
+func TestAmbusherSprint(t *testing.T) {
+	w := testWorld(2)
+	e := &Enemy{}
+	b := Ambusher.New()
+	const player = 30.0
+	for range 10 * (ambushWait + ambushSprint) {
+		dx := b.Move(w, e, player)
+		if math.Abs(dx) > sprintSpeed+1e-9 {
+			t.Fatalf("pulou %.2f células num tick em x = %.2f", dx, e.Pos)
+		}
+		e.Pos += dx
+	}
+	if math.Abs(e.Pos-player) > 1e-9 {
+		t.Errorf("emboscador parou em x = %.2f, queria %.0f", e.Pos, player)
+	}
+}
@@ -0,0 +1,170 @@
+package sim
+
+import (
+	"math"
+
+	"example/tesourim/utils"
+)
+
+// hexRowHeight é a distância vertical entre linhas de hexágonos, em larguras
+// de célula
+var hexRowHeight = math.Sqrt(3) / 2
+
+// Board descreve um tabuleiro completo: formato, dificuldade, tesouro e armadilhas
+type Board struct {
+	Width      int
+	Height     int
+	Void       map[int]bool   // Células fora do tabuleiro (nil = retângulo cheio)
+	Hex        bool           // Grade hexagonal (6 vizinhos) em vez de quadrada
+	Movement   utils.Movement // Regra de movimento da grade quadrada
+	Walls      []utils.Wall   // Paredes entre células vizinhas
+	HideWalls  bool           // Se as paredes somem depois da memorização
+	Hints      bool           // Células conhecidas mostram quantas armadilhas têm em volta
+	Dark       bool           // Depois da memorização, só se vê em volta do jogador
+	Difficulty int
+	Treasure   int
+	Traps      map[int]bool
+	TrapTypes  map[int]TrapType // Tipo de cada armadilha (as que faltam são buracos)
+}
+
+// SquareBoard é um tabuleiro L×L sem células vazias
+func SquareBoard(L int) Board {
+	return Board{Width: L, Height: L}
+}
+
+// Shape devolve o formato do tabuleiro
+func (b Board) Shape() utils.Shape {
+	return utils.Shape{Width: b.Width, Height: b.Height, Void: b.Void, Hex: b.Hex, Movement: b.Movement, Walls: b.Walls}
+}
+
+// Size é o maior lado do tabuleiro, que define o nível na progressão normal
+func (b Board) Size() int {
+	return max(b.Width, b.Height)
+}
+
+// Inside diz se a célula (x, y) faz parte do tabuleiro
+func (b Board) Inside(x, y int) bool {
+	return b.Shape().Inside(x, y)
+}
+
+// Node devolve o nó da célula (x, y)
+func (b Board) Node(x, y int) int {
+	return y*b.Width + x
+}
+
+// Move devolve a célula alcançada a partir de (x, y) com o passo (dx, dy),
+// com dy positivo para cima, ou false se a regra de movimento não permite o
+// passo. Em grades hexagonais só existem direita/esquerda e as quatro
+// diagonais. Na linha de entrada (y = -1) sempre dá para andar para os lados.
+// A célula devolvida pode estar fora do tabuleiro.
+func (b Board) Move(x, y, dx, dy int) (int, int, bool) {
+	if b.Hex {
+		return utils.HexStep(x, y, dx, dy)
+	}
+	if y == -1 && dy == 0 && (dx == 1 || dx == -1) {
+		return x + dx, y, true
+	}
+	if !b.Movement.Allows(dx, dy) {
+		return x, y, false
+	}
+	return x + dx, y + dy, true
+}
+
+// Blocked diz se uma parede impede o passo da célula (x1, y1) para (x2, y2)
+func (b Board) Blocked(x1, y1, x2, y2 int) bool {
+	if !b.Inside(x1, y1) || !b.Inside(x2, y2) {
+		return false
+	}
+	return b.Shape().Blocked(b.Node(x1, y1), b.Node(x2, y2))
+}
+
+// Distance é a distância entre duas células: euclidiana na grade quadrada e
+// em passos na hexagonal
+func (b Board) Distance(x1, y1, x2, y2 int) float64 {
+	if b.Hex {
+		return float64(utils.HexDistance(x1, y1, x2, y2))
+	}
+	return math.Hypot(float64(x2-x1), float64(y2-y1))
+}
+
+// Center devolve o centro da célula (x, y) no plano contínuo usado por pedras,
+// inimigos e projéteis: em larguras de célula, com a origem no centro da
+// célula de cima à esquerda e y crescendo para baixo
+func (b Board) Center(x, y int) (float64, float64) {
+	if b.Hex {
+		return float64(x) + 0.5*float64(y&1), float64(b.Height-1-y) * hexRowHeight
+	}
+	return float64(x), float64(b.Height - 1 - y)
+}
+
+// CellAt devolve a célula cujo centro está mais perto do ponto (fx, fy) do
+// plano de Center. A célula pode estar fora do tabuleiro.
+func (b Board) CellAt(fx, fy float64) (int, int) {
+	if !b.Hex {
+		return int(math.Round(fx)), b.Height - 1 - int(math.Round(fy))
+	}
+	row := b.Height - 1 - int(math.Round(fy/hexRowHeight))
+	bestX, bestY, best := 0, row, math.Inf(1)
+	for y := row - 1; y <= row+1; y++ {
+		guess := int(math.Round(fx - 0.5*float64(y&1)))
+		for x := guess - 1; x <= guess+1; x++ {
+			cx, cy := b.Center(x, y)
+			if d := math.Hypot(fx-cx, fy-cy); d < best {
+				bestX, bestY, best = x, y, d
+			}
+		}
+	}
+	return bestX, bestY
+}
+
+// Extent devolve a largura e a altura do desenho do tabuleiro, em larguras
+// de célula
+func (b Board) Extent() (float64, float64) {
+	if b.Hex {
+		return float64(b.Width) + 0.5, float64(b.Height-1)*hexRowHeight + 2/math.Sqrt(3)
+	}
+	return float64(b.Width), float64(b.Height)
+}
+
+// Starts devolve as células por onde o jogador entra no grid
+func (b Board) Starts() []int {
+	return b.Shape().Entrances()
+}
+
+// Graph devolve o grafo de vizinhança das células do tabuleiro
+func (b Board) Graph() map[int][]int {
+	return utils.GenerateShapeGraph(b.Shape())
+}
+
+// Valid diz se o formato e a regra de movimento são aceitos pelo jogo e se o
+// tesouro pode ser alcançado sem passar por armadilhas
+func (b Board) Valid() bool {
+	return b.Width >= 2 && b.Width <= MaxGridSize && b.Height >= 2 && b.Height <= MaxGridSize &&
+		b.Difficulty >= 1 && b.Difficulty <= 3 && b.Movement.Valid() && (!b.Hex || b.Movement == utils.King) &&
+		b.validTrapTypes() && b.Solvable()
+}
+
+// validTrapTypes diz se todo tipo de armadilha é conhecido e está numa armadilha
+func (b Board) validTrapTypes() bool {
+	for node, t := range b.TrapTypes {
+		if !t.Valid() || !b.Traps[node] {
+			return false
+		}
+	}
+	return true
+}
+
+// Solvable diz se o tesouro pode ser alcançado a partir de alguma entrada sem
+// passar por armadilhas, usando a regra de movimento do tabuleiro
+func (b Board) Solvable() bool {
+	if b.Treasure < 0 || b.Treasure >= b.Width*b.Height || b.Void[b.Treasure] || b.Traps[b.Treasure] {
+		return false
+	}
+	graph := b.Graph()
+	for _, start := range b.Starts() {
+		if utils.CanReach(graph, b.Traps, start, b.Treasure) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,292 @@
+package sim
+
+import (
+	"image/color"
+	"math"
+
+	"example/tesourim/utils"
+)
+
+// Constantes para o inimigo
+const (
+	BulletSpeed = 0.18
+	EnemyY      = -1.6 // Linha dos inimigos de cima, no plano de Board.Center
+	enemyGap    = 1.6  // Distância dos inimigos até as células mais perto deles
+)
+
+// Side é o lado do tabuleiro onde fica um inimigo. Cada inimigo anda ao longo
+// do seu lado e atira para dentro do tabuleiro.
+type Side int
+
+const (
+	Top Side = iota
+	Bottom
+	Left
+	Right
+)
+
+// Sides é o número de lados
+const Sides = 4
+
+var sideNames = [Sides]string{"Cima", "Baixo", "Esquerda", "Direita"}
+
+// Name devolve o nome do lado s
+func (s Side) Name() string {
+	if s < 0 || s >= Sides {
+		s = Top
+	}
+	return sideNames[s]
+}
+
+// inward devolve a direção dos tiros de quem está no lado s
+func (s Side) inward() (float64, float64) {
+	switch s {
+	case Bottom:
+		return 0, -1
+	case Left:
+		return 1, 0
+	case Right:
+		return -1, 0
+	}
+	return 0, 1
+}
+
+// Enemy representa o inimigo que se move e atira
+type Enemy struct {
+	Side          Side
+	Pos           float64              // Posição ao longo do lado: X em cima e embaixo, Y nos lados
+	pid           *utils.PIDController // Controle usado para perseguir um alvo
+	Bullets       []Bullet
+	lastShotTimer int
+	KillerMode    bool
+	target        float64 // Destino ao andar a esmo, ao longo do lado
+	Alive         bool
+	Kind          BehaviorKind  // Comportamento escolhido pelo nível
+	Behavior      EnemyBehavior // Estado do comportamento deste inimigo
+	Weapon        Weapon        // Como este inimigo atira
+}
+
+// Bullet representa um projétil
+type Bullet struct {
+	X, Y      float64
+	DX, DY    float64 // Velocidade, em células por tick
+	Active    bool
+	Owner     *Enemy // Referência ao inimigo que atirou esta bala
+	Reflected bool
+	Radius    float64    // Raio, em larguras de célula
+	Color     color.RGBA // Cor antes de ser refletido
+	TurnRate  float64    // Giro máximo por tick atrás do jogador, em radianos
+	Life      int        // Ticks até sumir (0 = sem limite)
+}
+
+// newEnemy cria um novo inimigo no lado side com o comportamento kind e a
+// arma weapon
+func (w *World) newEnemy(side Side, kind BehaviorKind, weapon WeaponKind) *Enemy {
+	e := &Enemy{
+		Side:     side,
+		Bullets:  make([]Bullet, 0),
+		Alive:    true,
+		Kind:     kind,
+		Behavior: kind.New(),
+		Weapon:   weapon.Weapon(),
+		pid:      utils.DifficultyPID(w.Level.Difficulty),
+	}
+	e.target = randomTarget(w, e)
+	return e
+}
+
+func (w *World) createEnemies() []*Enemy {
+	numEnemies := max(w.Level.Enemies, 0)
+
+	enemies := make([]*Enemy, numEnemies)
+	for i := range enemies {
+		enemies[i] = w.newEnemy(w.Level.Side(i), w.Level.Behavior(i), w.Level.Weapon(i))
+		_, enemies[i].Pos = w.Level.EnemyStart(i)
+	}
+	return enemies
+}
+
+// EnemyStart devolve o lado e a posição inicial do inimigo i. Os inimigos de
+// cada lado são distribuídos uniformemente ao longo dele.
+func (l Level) EnemyStart(i int) (Side, float64) {
+	side := l.Side(i)
+	count, index := 0, 0
+	for j := 0; j < max(l.Enemies, 0); j++ {
+		if l.Side(j) == side {
+			count++
+			if j <= i {
+				index++
+			}
+		}
+	}
+	spacing := (sideSpan(l.Board, side) + 1) / float64(count+1)
+	return side, spacing * float64(index)
+}
+
+// EnemyPoint devolve o ponto do plano de Center na posição pos do lado side,
+// onde andam os inimigos desse lado
+func (b Board) EnemyPoint(side Side, pos float64) (float64, float64) {
+	if side == Left || side == Right {
+		return sideLine(b, side), pos
+	}
+	return pos, sideLine(b, side)
+}
+
+// entranceY é o Y da linha de entrada no plano de Board.Center
+func entranceY(b Board) float64 {
+	_, y := b.Center(0, -1)
+	return y
+}
+
+// rightX é o maior X de uma célula no plano de Board.Center
+func rightX(b Board) float64 {
+	if b.Hex {
+		return float64(b.Width) - 0.5
+	}
+	return float64(b.Width - 1)
+}
+
+// sideSpan devolve o maior valor de Enemy.Pos no lado side
+func sideSpan(b Board, side Side) float64 {
+	if side == Left || side == Right {
+		return entranceY(b)
+	}
+	return float64(b.Width - 1)
+}
+
+// sideLine devolve onde fica a linha dos inimigos do lado side: o Y dos
+// lados de cima e de baixo, ou o X dos lados esquerdo e direito
+func sideLine(b Board, side Side) float64 {
+	switch side {
+	case Bottom:
+		return entranceY(b) + enemyGap
+	case Left:
+		return -enemyGap
+	case Right:
+		return rightX(b) + enemyGap
+	}
+	return EnemyY
+}
+
+// span devolve o maior valor de Pos no lado do inimigo
+func (e *Enemy) span(b Board) float64 {
+	return sideSpan(b, e.Side)
+}
+
+// Position devolve o centro do inimigo no plano de Board.Center
+func (e *Enemy) Position(b Board) (float64, float64) {
+	return b.EnemyPoint(e.Side, e.Pos)
+}
+
+// Track devolve a posição do jogador ao longo do lado do inimigo e a
+// distância dele até a linha do inimigo
+func (e *Enemy) Track(w *World) (float64, float64) {
+	px, py := w.Level.Center(w.PlayerX, w.PlayerY)
+	if e.Side == Left || e.Side == Right {
+		return py, math.Abs(px - sideLine(w.Level.Board, e.Side))
+	}
+	return px, math.Abs(py - sideLine(w.Level.Board, e.Side))
+}
+
+// update atualiza a posição do inimigo e seus projéteis
+func (e *Enemy) update(w *World) {
+	if !e.Alive {
+		return
+	}
+	player, _ := e.Track(w)
+
+	dx := e.Behavior.Move(w, e, player)
+	// Mantém o inimigo dentro do seu lado
+	e.Pos = min(max(e.Pos+dx, 0), e.span(w.Level.Board))
+
+	// Atualiza timer de tiro
+	if e.lastShotTimer > 0 {
+		e.lastShotTimer--
+	}
+
+	// Tenta atirar quando o comportamento pede
+	if e.lastShotTimer == 0 && e.Behavior.Fire(w, e, player) {
+		if utils.RussianRoulette(w.rng, w.Level.Difficulty) {
+			e.Bullets = append(e.Bullets, e.shoot(w, true)...)
+			e.lastShotTimer = e.Weapon.Cooldown
+		}
+		if !utils.RussianRoulette(w.rng, w.Level.Difficulty) {
+			e.Bullets = append(e.Bullets, e.shoot(w, false)...)
+			e.lastShotTimer = e.Weapon.Cooldown
+		}
+	}
+
+	// Atualiza projéteis
+	ex, ey := e.Position(w.Level.Board)
+	left, top, right, bottom := w.arena()
+	for i := range e.Bullets {
+		b := &e.Bullets[i]
+		if !b.Active {
+			continue
+		}
+		b.move(w)
+
+		// Verifica colisão com o inimigo para projéteis refletidos
+		if b.Reflected {
+			if math.Round(b.X) == math.Round(ex) && math.Round(b.Y) == math.Round(ey) && e.Alive {
+				e.Alive = false
+				b.Active = false
+			}
+		}
+
+		// Desativa projéteis que passaram da linha dos inimigos
+		if b.X < left-1 || b.X > right+1 || b.Y < top-1 || b.Y > bottom+1 {
+			b.Active = false
+		}
+		// Como no jogo original, os projéteis de cima somem ao chegar no
+		// centro da linha de entrada
+		if e.Side == Top && !b.Reflected && b.Y >= entranceY(w.Level.Board) {
+			b.Active = false
+		}
+	}
+
+	// Remove projéteis inativos
+	active := make([]Bullet, 0)
+	for _, b := range e.Bullets {
+		if b.Active {
+			active = append(active, b)
+		}
+	}
+	e.Bullets = active
+}
+
+// arena devolve as linhas dos inimigos dos quatro lados, que cercam o
+// tabuleiro e a linha de entrada
+func (w *World) arena() (left, top, right, bottom float64) {
+	b := w.Level.Board
+	return sideLine(b, Left), sideLine(b, Top), sideLine(b, Right), sideLine(b, Bottom)
+}
+
+// reflect manda o projétil b de volta por onde veio, para o lado de quem
+// atirou
+func (b *Bullet) reflect() {
+	if !b.Reflected {
+		b.DX, b.DY = -b.DX, -b.DY
+	}
+	b.Reflected = true
+}
+
+// chase devolve o passo do inimigo em direção a target, usando PID
+func (e *Enemy) chase(target float64) float64 {
+	return e.pid.Update(target, e.Pos)
+}
+
+func (w *World) setKillerMode(e *Enemy, mode bool) {
+	// Se está entrando no modo killer
+	if mode && !e.KillerMode {
+		// Só entra se não exceder o limite
+		if w.killersCount < w.maxKillers {
+			e.KillerMode = true
+			e.pid.Reset()
+			w.killersCount++
+		}
+	} else if !mode && e.KillerMode { // Se está saindo do modo killer
+		e.KillerMode = false
+		w.killersCount--
+	}
+}
@@ -0,0 +1,83 @@
+package sim
+
+import (
+	"math"
+	"testing"
+)
+
+// TestEnemyStart confere a distribuição dos inimigos nos lados do tabuleiro
+// de testWorld: dois em cima e um à esquerda
+func TestEnemyStart(t *testing.T) {
+	l := testWorld(2).Level
+	l.Enemies = 3
+	l.Sides = []Side{Top, Left}
+	tests := []struct {
+		side Side
+		pos  float64
+	}{
+		{Top, 2},
+		{Left, 3.5},
+		{Top, 4},
+	}
+	for i, tt := range tests {
+		side, pos := l.EnemyStart(i)
+		if side != tt.side || pos != tt.pos {
+			t.Errorf("inimigo %d: %s em %.2f, queria %s em %.2f", i, side.Name(), pos, tt.side.Name(), tt.pos)
+		}
+	}
+}
+
+// TestTrack confere a posição do jogador vista de cada lado, com ele na
+// entrada, em (0, 6) no plano de Board.Center
+func TestTrack(t *testing.T) {
+	w := testWorld(2)
+	tests := []struct {
+		side        Side
+		along, dist float64
+	}{
+		{Top, 0, 6 - EnemyY},
+		{Bottom, 0, enemyGap},
+		{Left, 6, enemyGap},
+		{Right, 6, 5 + enemyGap},
+	}
+	for _, tt := range tests {
+		along, dist := (&Enemy{Side: tt.side}).Track(w)
+		if math.Abs(along-tt.along) > 1e-9 || math.Abs(dist-tt.dist) > 1e-9 {
+			t.Errorf("%s: jogador em %.2f a %.2f, queria %.2f a %.2f", tt.side.Name(), along, dist, tt.along, tt.dist)
+		}
+	}
+}
+
+// TestTopBulletsStopAtEntrance confere que os projéteis de quem está em cima,
+// retos ou em leque, somem ao chegar no centro da linha de entrada, como no
+// jogo original
+func TestTopBulletsStopAtEntrance(t *testing.T) {
+	for _, weapon := range []WeaponKind{Straight, Fan} {
+		for _, hex := range []bool{false, true} {
+			w := NewWorld(1)
+			l := NewLevel(generate(t, 1, Board{Width: 7, Height: 7, Hex: hex}, 2))
+			l.Enemies = 2
+			l.Behaviors = []BehaviorKind{Tracker}
+			l.Weapons = []WeaponKind{weapon}
+			l.GameTime = 1 << 30
+			w.LoadLevel(l)
+			w.Step(Input{Throw: true})
+
+			limit, shots := entranceY(l.Board), 0
+			for tick := 0; tick < 3000 && w.State == Playing; tick++ {
+				w.Step(Input{})
+				for _, e := range w.Enemies {
+					for _, b := range e.Bullets {
+						shots++
+						if b.Active && b.Y >= limit {
+							t.Fatalf("%s, hex=%v, tick %d: projétil em y=%.2f, depois da entrada em y=%.2f", weapon.Name(), hex, tick, b.Y, limit)
+						}
+					}
+				}
+			}
+			if shots == 0 {
+				t.Errorf("%s, hex=%v: nenhum tiro", weapon.Name(), hex)
+			}
+		}
+	}
+}
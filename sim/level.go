@@ -0,0 +1,224 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"example/tesourim/utils"
+)
+
+// ErrUnsolvable é devolvido por GenerateBoard quando nenhuma das tentativas
+// deixa um caminho seguro até o tesouro
+var ErrUnsolvable = errors.New("sim: nenhum tabuleiro com caminho até o tesouro")
+
+// Level é a configuração de um nível: o tabuleiro e os limites de cada tentativa
+type Level struct {
+	Board
+	Title        string // Nome do nível (só os feitos à mão têm)
+	Enemies      int    // Número de inimigos
+	Start        int    // Coluna da linha de entrada onde o jogador começa
+	GameTime     int    // Tempo para achar o tesouro, em ticks
+	MemorizeTime int    // Duração da fase de memorização, em ticks
+	Lives        int    // Vidas no começo de cada tentativa
+	Rocks        int    // Pedras no começo de cada tentativa
+	Torches      int    // Tochas no começo de cada tentativa (só servem no modo Board.Dark)
+
+	// Comportamento, lado e arma de cada inimigo, em ordem. As listas se
+	// repetem e, vazias, todos são Wanderer, ficam em cima e usam a arma do
+	// seu comportamento.
+	Behaviors []BehaviorKind
+	Sides     []Side
+	Weapons   []WeaponKind
+
+	Walkers []WalkerSpec // Inimigos que andam dentro do tabuleiro
+}
+
+// NewLevel monta um nível com os limites que a progressão normal dá para o
+// tamanho do tabuleiro: +2 minutos por tamanho, +1 vida a cada tamanho par e
+// inimigos a partir do grid 7
+func NewLevel(b Board) Level {
+	l := Level{
+		Board:        b,
+		Enemies:      defaultEnemies(b.Size()),
+		GameTime:     15 * 60,
+		MemorizeTime: 30 * 60,
+		Lives:        2,
+		Rocks:        5,
+		Torches:      1,
+	}
+	if size := b.Size(); size > 6 {
+		l.GameTime += (size - 6) * 60 * 2
+		l.Lives += (size - 6) / 2
+	}
+	return l
+}
+
+// Behavior devolve o comportamento do inimigo i
+func (l Level) Behavior(i int) BehaviorKind {
+	if len(l.Behaviors) == 0 {
+		return Wanderer
+	}
+	return l.Behaviors[i%len(l.Behaviors)]
+}
+
+// Side devolve o lado do inimigo i
+func (l Level) Side(i int) Side {
+	if len(l.Sides) == 0 {
+		return Top
+	}
+	return l.Sides[i%len(l.Sides)]
+}
+
+// Weapon devolve a arma do inimigo i
+func (l Level) Weapon(i int) WeaponKind {
+	if len(l.Weapons) == 0 {
+		return l.Behavior(i).Weapon()
+	}
+	return l.Weapons[i%len(l.Weapons)]
+}
+
+// defaultEnemies é o número de inimigos da progressão normal num tabuleiro
+// de tamanho size
+func defaultEnemies(size int) int {
+	numEnemies := size - 6 // Começa com 1 inimigo no grid 7, +1 a cada grid
+	if numEnemies > 3 {
+		numEnemies = 3 // Máximo de 3 inimigos
+	} else if numEnemies < 0 {
+		numEnemies = 0 // Tabuleiros menores que 6 não têm inimigos
+	}
+	return numEnemies
+}
+
+// difficultyIndex leva dificulty para 1 a 3, os índices das tabelas por
+// dificuldade
+func difficultyIndex(dificulty int) int {
+	return min(max(dificulty, 1), 3)
+}
+
+// difficultyBands é a faixa de utils.BoardScore.Score pedida em cada dificuldade
+var difficultyBands = [...][2]float64{
+	1: {0.3, 0.9},
+	2: {0.9, 1.6},
+	3: {1.6, math.Inf(1)},
+}
+
+// pathOptions é o caminho seguro escavado em cada dificuldade
+func pathOptions(L, dificulty int) utils.PathOptions {
+	switch dificulty {
+	case 1:
+		return utils.PathOptions{Length: L, Turns: 1, Diagonals: true}
+	case 2:
+		return utils.PathOptions{Length: L * 3 / 2, Turns: 3, Diagonals: true}
+	default:
+		return utils.PathOptions{Length: 2 * L, Turns: 5, Diagonals: false}
+	}
+}
+
+// generateBoard gera alguns tabuleiros no formato de shape e fica com o
+// primeiro cuja nota cai na faixa da dificuldade (ou com o mais próximo dela),
+// descartando os que não têm caminho até o tesouro.
+// Cada tabuleiro ganha walls paredes fora do caminho seguro; as paredes que
+// shape já tivesse são descartadas. Com shape.Hints, o tabuleiro perde as
+// armadilhas necessárias para dar para chegar ao tesouro só pelas dicas.
+// Formatos sem entrada dão utils.ErrNoEntrance e, se nenhuma tentativa tiver
+// caminho, ErrUnsolvable.
+func (w *World) generateBoard(shape Board, dificulty, walls int) (Board, error) {
+	const attempts = 30
+	dificulty = difficultyIndex(dificulty)
+	shape.Walls = nil
+	graph := shape.Graph()
+	starts := shape.Starts()
+	band := difficultyBands[dificulty]
+	L := (shape.Width + shape.Height) / 2
+
+	best, bestDist := Board{}, math.Inf(1)
+	for i := 0; i < attempts; i++ {
+		b := shape
+		b.Difficulty = dificulty
+		treasure, traps, path, err := utils.GenerateBoard(w.rng, shape.Shape(), dificulty, pathOptions(L, dificulty))
+		if err != nil {
+			return Board{}, err
+		}
+		b.Treasure, b.Traps = treasure, traps
+		g := graph
+		if walls > 0 {
+			b.Walls = utils.GenerateWalls(w.rng, shape.Shape(), path, walls)
+			g = b.Graph()
+		}
+		if b.Hints {
+			b.Traps = utils.MakeHintSolvable(g, b.Traps, starts, b.Treasure)
+		}
+		bs := utils.ScoreBoard(g, b.Traps, starts, b.Treasure)
+		if bs.PathLength == 0 {
+			continue
+		}
+		dist := math.Max(band[0]-bs.Score, bs.Score-band[1])
+		if dist < bestDist {
+			best, bestDist = b, dist
+		}
+		if dist <= 0 {
+			break
+		}
+	}
+	if math.IsInf(bestDist, 1) {
+		return Board{}, ErrUnsolvable
+	}
+	// Os tipos das armadilhas não mudam a nota: para o caminho seguro, toda
+	// armadilha é proibida
+	best.TrapTypes = generateTrapTypes(w.rng, best.Traps, dificulty)
+	return best, nil
+}
+
+// movementTwists são os níveis (tamanho, dificuldade) da progressão normal
+// que trocam a regra de movimento, como surpresa para o jogador
+var movementTwists = map[[2]int]utils.Movement{
+	{8, 2}:  utils.Orthogonal,
+	{10, 2}: utils.Diagonal,
+	{12, 2}: utils.Knight,
+}
+
+// nextLevel passa para o próximo nível da campanha ou, depois dela, sobe a
+// dificuldade e, depois da dificuldade 3, aumenta o grid. Vencer a
+// dificuldade 3 no maior grid termina o jogo.
+func (w *World) nextLevel() Level {
+	w.inCampaign = w.CampaignStep < len(w.Campaign)
+	if w.inCampaign {
+		w.CampaignStep++
+		return w.Campaign[w.CampaignStep-1]
+	}
+	size, dificulty := min(w.Level.Size(), MaxGridSize), max(w.Level.Difficulty, 0)+1
+	if dificulty > 3 {
+		if size < MaxGridSize {
+			dificulty = 1
+			size++
+		} else {
+			dificulty = 3
+			w.EndGame = true
+		}
+	}
+	shape := SquareBoard(size)
+	shape.Movement = movementTwists[[2]int{size, dificulty}]
+	return NewLevel(w.generateSquare(shape, dificulty))
+}
+
+// generateSquare é generateBoard sem paredes para os quadrados da progressão
+// normal, com qualquer regra de movimento. Um quadrado sempre tem entrada e um
+// caminho escavado até o tesouro; se não tiver, o gerador está quebrado e o
+// jogo para em vez de seguir com um tabuleiro vazio.
+func (w *World) generateSquare(shape Board, dificulty int) Board {
+	b, err := w.generateBoard(shape, dificulty, 0)
+	if err != nil {
+		panic(fmt.Sprintf("sim: quadrado %d×%d (movimento %d, dificuldade %d) sem tabuleiro resolvível: %v",
+			shape.Width, shape.Height, shape.Movement, dificulty, err))
+	}
+	return b
+}
+
+// GenerateBoard gera um tabuleiro avulso com o formato, a grade, a regra de
+// movimento, HideWalls, Hints e Dark de shape (o resto de shape é ignorado), na
+// dificuldade pedida e com walls paredes, como os da progressão normal. Um
+// formato sem entrada dá utils.ErrNoEntrance, e um sem caminho, ErrUnsolvable.
+func GenerateBoard(seed int64, shape Board, dificulty, walls int) (Board, error) {
+	return newWorld(seed, utils.NewRand(seed)).generateBoard(shape, dificulty, walls)
+}
@@ -0,0 +1,129 @@
+package sim
+
+import (
+	"testing"
+
+	"example/tesourim/utils"
+)
+
+// generate é GenerateBoard sem paredes, para formatos que têm entrada
+func generate(t *testing.T, seed int64, shape Board, dificulty int) Board {
+	t.Helper()
+	b, err := GenerateBoard(seed, shape, dificulty, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestCampaign joga uma campanha de dois níveis e confere que a progressão
+// normal continua do último deles
+func TestCampaign(t *testing.T) {
+	a := NewLevel(generate(t, 1, SquareBoard(6), 1))
+	a.Title = "A"
+	b := NewLevel(generate(t, 2, Board{Width: 7, Height: 6}, 2))
+	b.Title, b.Lives = "B", 5
+
+	w := NewCampaignWorld(1, []Level{a, b})
+	for i, title := range []string{"A", "B"} {
+		if w.Level.Title != title || w.CampaignStep != i+1 {
+			t.Fatalf("rodada %d: nível %q, passo %d; queria %q", w.Round, w.Level.Title, w.CampaignStep, title)
+		}
+		w.State = Won
+		w.Step(Input{Advance: true})
+	}
+	if w.Lives != NewLevel(w.Level.Board).Lives || w.Level.Title != "" {
+		t.Errorf("depois da campanha: nível %q com %d vidas", w.Level.Title, w.Lives)
+	}
+	if w.Level.Size() != 7 || w.Level.Difficulty != 3 {
+		t.Errorf("depois da campanha: grid %d, dificuldade %d; queria 7, 3", w.Level.Size(), w.Level.Difficulty)
+	}
+}
+
+// TestProgression vence todos os níveis da progressão normal, do grid 6 na
+// dificuldade 1 até a dificuldade 3 no maior grid, que termina o jogo
+func TestProgression(t *testing.T) {
+	w := NewWorld(1)
+	want := []int{6, 1}
+	for !w.EndGame {
+		if got := []int{w.Level.Size(), w.Level.Difficulty}; got[0] != want[0] || got[1] != want[1] {
+			t.Fatalf("rodada %d: grid %d, dificuldade %d; queria grid %d, dificuldade %d", w.Round, got[0], got[1], want[0], want[1])
+		}
+		if want[1]++; want[1] > 3 && want[0] < MaxGridSize {
+			want = []int{want[0] + 1, 1}
+		}
+		w.State = Won
+		w.Step(Input{Advance: true})
+		if w.Round > 100 {
+			t.Fatal("a progressão não termina")
+		}
+	}
+	if w.Level.Size() != MaxGridSize || w.Level.Difficulty != 3 {
+		t.Errorf("fim de jogo no grid %d, dificuldade %d; queria %d, 3", w.Level.Size(), w.Level.Difficulty, MaxGridSize)
+	}
+	if w.Round != (MaxGridSize-6+1)*3+1 {
+		t.Errorf("fim de jogo na rodada %d", w.Round)
+	}
+
+	// Depois do fim, a contagem regressiva fecha o jogo
+	for i := 0; i < 200 && !w.Over; i++ {
+		w.Step(Input{})
+	}
+	if !w.Over {
+		t.Error("o jogo não terminou depois da contagem")
+	}
+}
+
+// TestNextLevelClampsDifficulty confere que o nível seguinte a uma
+// dificuldade fora de 1 a 3 (de um nível feito à mão) não sai das tabelas
+func TestNextLevelClampsDifficulty(t *testing.T) {
+	for _, dificulty := range []int{-2, 0, 3, 7} {
+		w := newWorld(1, utils.NewRand(1))
+		w.Level = NewLevel(SquareBoard(MaxGridSize))
+		w.Level.Difficulty = dificulty
+		l := w.nextLevel()
+		if l.Difficulty < 1 || l.Difficulty > 3 || l.Size() > MaxGridSize {
+			t.Errorf("dificuldade %d: próximo nível no grid %d, dificuldade %d", dificulty, l.Size(), l.Difficulty)
+		}
+	}
+}
+
+// TestGenerateBoardDiagonalPinch gera tabuleiros num formato com uma
+// diagonal entre duas células vazias, que o rei não atravessa, e confere que
+// todos têm caminho até o tesouro
+func TestGenerateBoardDiagonalPinch(t *testing.T) {
+	shape := Board{Width: 5, Height: 5, Void: map[int]bool{1: true, 5: true, 8: true, 12: true}}
+	for dificulty := 1; dificulty <= 3; dificulty++ {
+		for seed := int64(1); seed <= 20; seed++ {
+			if b := generate(t, seed, shape, dificulty); !b.Solvable() {
+				t.Errorf("dificuldade %d, seed %d: tesouro %d inalcançável", dificulty, seed, b.Treasure)
+			}
+		}
+	}
+}
+
+// TestGenerateSquare confere que todo quadrado da progressão normal, com
+// qualquer regra de movimento, gera um tabuleiro resolvível (generateSquare
+// entra em pânico se não gerar)
+func TestGenerateSquare(t *testing.T) {
+	w := newWorld(1, utils.NewRand(1))
+	for size := 4; size <= MaxGridSize; size++ {
+		for dificulty := 1; dificulty <= 3; dificulty++ {
+			for m := utils.Movement(0); m < utils.Movements; m++ {
+				shape := SquareBoard(size)
+				shape.Movement = m
+				if b := w.generateSquare(shape, dificulty); !b.Solvable() {
+					t.Errorf("grid %d, dificuldade %d, movimento %d: tesouro inalcançável", size, dificulty, m)
+				}
+			}
+		}
+	}
+}
+
+func TestDefaultEnemies(t *testing.T) {
+	for size, want := range map[int]int{4: 0, 6: 0, 7: 1, 9: 3, 10: 3, MaxGridSize: 3} {
+		if got := defaultEnemies(size); got != want {
+			t.Errorf("grid %d: %d inimigos, queria %d", size, got, want)
+		}
+	}
+}
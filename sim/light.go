@@ -0,0 +1,71 @@
+package sim
+
+import "math"
+
+// lightRadius é o raio de luz em volta do jogador no modo Board.Dark, em
+// larguras de célula, em cada dificuldade
+var lightRadius = [4]float64{1: 2.5, 2: 2, 3: 1.5}
+
+const (
+	torchBonus = 1.5    // Raio a mais enquanto a tocha está acesa
+	torchTicks = 8 * 60 // Quanto tempo uma tocha fica acesa
+	glowRadius = 1.2    // Raio da luz deixada por uma pedra
+	glowTicks  = 2 * 60 // Quanto tempo a luz de uma pedra dura
+)
+
+// Glow é a luz que uma pedra deixa por um instante onde caiu
+type Glow struct {
+	X, Y  float64 // Centro, no plano de Board.Center
+	Ticks int     // Ticks até apagar
+}
+
+// LightRadius devolve o raio de luz atual em volta do jogador
+func (w *World) LightRadius() float64 {
+	radius := lightRadius[difficultyIndex(w.Level.Difficulty)]
+	if w.TorchTimer > 0 {
+		radius += torchBonus
+	}
+	return radius
+}
+
+// Lit diz se o ponto (x, y) do plano de Board.Center está visível. Fora do
+// modo Board.Dark, e na memorização ou no fim do nível, tudo está visível.
+func (w *World) Lit(x, y float64) bool {
+	if !w.Level.Dark || w.State == Memorizing || w.State == Won || w.State == Lost {
+		return true
+	}
+	px, py := w.Level.Center(w.PlayerX, w.PlayerY)
+	if math.Hypot(x-px, y-py) <= w.LightRadius() {
+		return true
+	}
+	for _, g := range w.Glows {
+		if math.Hypot(x-g.X, y-g.Y) <= glowRadius {
+			return true
+		}
+	}
+	return false
+}
+
+// LitNode diz se a célula do nó node está visível
+func (w *World) LitNode(node int) bool {
+	return w.Lit(w.Level.Center(node%w.Level.Width, node/w.Level.Width))
+}
+
+// updateLight acende a tocha pedida em in e apaga aos poucos a tocha e a luz
+// das pedras
+func (w *World) updateLight(in Input) {
+	if w.TorchTimer > 0 {
+		w.TorchTimer--
+	}
+	if in.Torch && w.Level.Dark && w.TorchesLeft > 0 && w.TorchTimer == 0 {
+		w.TorchesLeft--
+		w.TorchTimer = torchTicks
+	}
+	glows := w.Glows[:0]
+	for _, g := range w.Glows {
+		if g.Ticks--; g.Ticks > 0 {
+			glows = append(glows, g)
+		}
+	}
+	w.Glows = glows
+}
@@ -0,0 +1,41 @@
+package sim
+
+import "testing"
+
+// TestLight confere o raio de luz no escuro, a tocha e a luz das pedras. O
+// jogador fica na entrada, em (0, -1), com raio 2,5 na dificuldade 1.
+func TestLight(t *testing.T) {
+	w := testWorld(2)
+	if !w.LitNode(35) {
+		t.Error("fora do modo escuro, o canto oposto está apagado")
+	}
+	w.Level.Dark = true
+	if !w.LitNode(6) || w.LitNode(12) {
+		t.Errorf("sem tocha: nó 6 aceso = %v, nó 12 aceso = %v; queria true, false", w.LitNode(6), w.LitNode(12))
+	}
+
+	w.Step(Input{Torch: true})
+	if w.TorchesLeft != 0 || w.TorchTimer != torchTicks {
+		t.Fatalf("depois da tocha: %d tochas, timer %d", w.TorchesLeft, w.TorchTimer)
+	}
+	if !w.LitNode(12) || w.LitNode(5) {
+		t.Errorf("com tocha: nó 12 aceso = %v, nó 5 aceso = %v; queria true, false", w.LitNode(12), w.LitNode(5))
+	}
+	for range torchTicks {
+		w.Step(Input{})
+	}
+	if w.LitNode(12) {
+		t.Error("a tocha não apagou")
+	}
+
+	x, y := w.Level.Center(5, 5)
+	w.Glows = append(w.Glows, Glow{X: x, Y: y, Ticks: 2})
+	if !w.LitNode(35) {
+		t.Error("a luz da pedra não acendeu o nó 35")
+	}
+	w.Step(Input{})
+	w.Step(Input{})
+	if w.LitNode(35) || len(w.Glows) != 0 {
+		t.Errorf("a luz da pedra não apagou: %+v", w.Glows)
+	}
+}
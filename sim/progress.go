@@ -0,0 +1,126 @@
+package sim
+
+import (
+	"maps"
+
+	"example/tesourim/utils"
+)
+
+// Progress é o que precisa ser guardado para continuar uma partida depois
+type Progress struct {
+	Round        int
+	CampaignStep int   // Nível da campanha em andamento (0 = fora da campanha)
+	Level        Level // Nível em andamento, com o tabuleiro e os limites
+	Lives        int
+	RocksLeft    int
+	TorchesLeft  int
+	FallenTraps  map[int]bool
+	Cracked      map[int]bool
+	Visited      map[int]bool
+	Flags        map[int]bool
+	GameTimer    int
+	Stuck        int
+	Memorizing   bool // Se o nível ainda estava na fase de memorização
+	Won          bool // Se o nível já tinha sido vencido: a partida continua no seguinte
+}
+
+// Progress devolve o progresso atual. Uma partida perdida é guardada como se
+// o jogador já tivesse reiniciado o nível, e uma vencida, como vencida, para
+// continuar no próximo nível.
+func (w *World) Progress() Progress {
+	// Uma partida pausada é guardada na fase em que foi pausada
+	state := w.State
+	if state == Paused {
+		state = w.resumeState
+	}
+	p := Progress{
+		Round:        w.Round,
+		CampaignStep: w.CampaignStep,
+		Level:        w.Level,
+		Lives:        w.Lives,
+		RocksLeft:    w.RocksLeft,
+		TorchesLeft:  w.TorchesLeft,
+		FallenTraps:  w.FallenTraps,
+		Cracked:      w.Cracked,
+		Visited:      w.Visited,
+		Flags:        w.Flags,
+		GameTimer:    w.GameTimer,
+		Stuck:        w.Stuck,
+		Memorizing:   state == Memorizing,
+		Won:          state == Won,
+	}
+	// Depois da campanha, ou num nível de fora dela, a partida não volta
+	// para a campanha
+	if !w.inCampaign {
+		p.CampaignStep = 0
+	}
+	if state == Lost {
+		p.Lives = w.Level.Lives
+		p.RocksLeft = w.Level.Rocks
+		p.TorchesLeft = w.Level.Torches
+		p.FallenTraps = make(map[int]bool)
+		p.Cracked = make(map[int]bool)
+		p.Visited = nil
+		p.Flags = nil
+		p.GameTimer = w.Level.GameTime
+		p.Stuck = 0
+		p.Memorizing = true
+	}
+	return p
+}
+
+// LevelProgress é o progresso de quem acabou de entrar no nível l, para
+// começar uma partida avulsa nele com ResumeWorld
+func LevelProgress(l Level) Progress {
+	return Progress{
+		Round:       1,
+		Level:       l,
+		Lives:       l.Lives,
+		RocksLeft:   l.Rocks,
+		TorchesLeft: l.Torches,
+		GameTimer:   l.GameTime,
+		Memorizing:  true,
+	}
+}
+
+// ResumeWorld recria uma partida a partir de um progresso salvo. Os sorteios
+// seguem da seed original misturada com o nível, já que o estado do gerador
+// não é salvo. campaign é a campanha da partida, de onde vêm os níveis
+// seguintes se o nível em andamento for dela. Se o nível já tinha sido
+// vencido, a partida começa no próximo, como depois do ENTER. Os mapas de p
+// são copiados, então p não muda com a partida.
+func ResumeWorld(seed int64, p Progress, campaign []Level) *World {
+	w := newWorld(seed, utils.NewRand(seed+int64(p.Round)))
+	if p.CampaignStep > 0 && p.CampaignStep <= len(campaign) {
+		w.Campaign, w.CampaignStep, w.inCampaign = campaign, p.CampaignStep, true
+	}
+	w.enterLevel(p.Level)
+	w.Round = p.Round
+	if p.Won {
+		w.enterLevel(w.nextLevel())
+		return w
+	}
+	w.Lives = p.Lives
+	w.RocksLeft = p.RocksLeft
+	w.TorchesLeft = p.TorchesLeft
+	w.GameTimer = p.GameTimer
+	w.Stuck = p.Stuck
+	if p.FallenTraps != nil {
+		w.FallenTraps = maps.Clone(p.FallenTraps)
+	}
+	if p.Cracked != nil {
+		w.Cracked = maps.Clone(p.Cracked)
+	}
+	if p.Visited != nil {
+		w.Visited = maps.Clone(p.Visited)
+	}
+	if p.Flags != nil {
+		w.Flags = maps.Clone(p.Flags)
+	}
+	if !p.Memorizing {
+		w.State = Playing
+		w.ShowTraps = false
+		w.Message = ""
+	}
+	return w
+}
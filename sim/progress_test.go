@@ -0,0 +1,95 @@
+package sim
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestResume salva o progresso no meio de um nível e continua dele: o
+// nível, as vidas, as pedras, as tochas e as armadilhas caídas voltam iguais
+func TestResume(t *testing.T) {
+	w := testWorld(2)
+	w.Level.Title, w.Level.Enemies, w.Level.Rocks = "Feito à mão", 2, 7
+	w.Level.Behaviors = []BehaviorKind{Tracker}
+	w.Level.Walkers = []WalkerSpec{{Kind: Scout, Route: []int{30, 35}}}
+	play(w, up, right, up)
+	w.Step(Input{Flag: true, FlagX: 1, FlagY: 1})
+	w.Lives, w.RocksLeft, w.TorchesLeft = 1, 3, 0
+	w.Cracked[13], w.Stuck = true, 2
+	p := w.Progress()
+
+	r := ResumeWorld(1, p, nil)
+	if !reflect.DeepEqual(r.Level, w.Level) {
+		t.Errorf("nível %+v, queria %+v", r.Level, w.Level)
+	}
+	if len(r.Enemies) != 2 || len(r.Walkers) != 1 {
+		t.Errorf("%d inimigos e %d no tabuleiro, queria 2 e 1", len(r.Enemies), len(r.Walkers))
+	}
+	if r.State != Playing || r.Round != w.Round || r.Lives != 1 || r.RocksLeft != 3 || r.TorchesLeft != 0 || r.GameTimer != w.GameTimer {
+		t.Errorf("estado %d, rodada %d, %d vidas, %d pedras, %d tochas, timer %d", r.State, r.Round, r.Lives, r.RocksLeft, r.TorchesLeft, r.GameTimer)
+	}
+	if !reflect.DeepEqual(r.FallenTraps, map[int]bool{1: true}) || !reflect.DeepEqual(r.Cracked, w.Cracked) || r.Stuck != 2 {
+		t.Errorf("armadilhas caídas %v, pisos rachados %v, preso por %d; queria o buraco 1, o piso 13 e 2", r.FallenTraps, r.Cracked, r.Stuck)
+	}
+	if !reflect.DeepEqual(r.Visited, w.Visited) || !reflect.DeepEqual(r.Flags, map[int]bool{7: true}) {
+		t.Errorf("células conhecidas %v e bandeiras %v, queria %v e a bandeira 7", r.Visited, r.Flags, w.Visited)
+	}
+	if r.PlayerX != 0 || r.PlayerY != -1 {
+		t.Errorf("jogador em (%d, %d), queria a entrada", r.PlayerX, r.PlayerY)
+	}
+
+	// Perdido, o nível volta do começo
+	w.GameTimer = 1
+	w.Step(Input{})
+	r = ResumeWorld(1, w.Progress(), nil)
+	if r.State != Memorizing || r.Lives != w.Level.Lives || len(r.FallenTraps) != 0 || len(r.Cracked) != 0 || r.Stuck != 0 {
+		t.Errorf("depois da derrota: estado %d, %d vidas, %d armadilhas caídas, %d pisos rachados, preso por %d",
+			r.State, r.Lives, len(r.FallenTraps), len(r.Cracked), r.Stuck)
+	}
+
+	// Vencido, continua no próximo nível, como depois do ENTER
+	w = testWorld(2)
+	play(w, up, up, up, right, right)
+	if w.State != Won {
+		t.Fatalf("estado %d, queria Won", w.State)
+	}
+	r = ResumeWorld(1, w.Progress(), nil)
+	if r.State != Memorizing || r.Round != w.Round+1 || r.Level.Difficulty != 2 || r.Level.Size() != 6 || r.Lives != r.Level.Lives {
+		t.Errorf("depois da vitória: estado %d, rodada %d, dificuldade %d, grid %d, %d vidas; queria o nível seguinte, na rodada %d",
+			r.State, r.Round, r.Level.Difficulty, r.Level.Size(), r.Lives, w.Round+1)
+	}
+}
+
+// TestResumeCampaign salva e retoma a partida num nível da campanha e num
+// tabuleiro gerado depois dela
+func TestResumeCampaign(t *testing.T) {
+	first := NewLevel(generate(t, 1, SquareBoard(6), 1))
+	first.Title, first.GameTime = "Primeiro", 123
+	last := NewLevel(generate(t, 2, SquareBoard(7), 2))
+	last.Title, last.GameTime = "Último", 456
+	last.Walkers = []WalkerSpec{{Kind: Scout, Route: []int{0}}}
+	campaign := []Level{first, last}
+
+	w := NewCampaignWorld(1, campaign)
+	for _, want := range []string{"Primeiro", "Último", ""} {
+		p := w.Progress()
+		r := ResumeWorld(w.Seed, p, campaign)
+		if r.Level.Title != want || r.Level.GameTime != w.Level.GameTime || len(r.Walkers) != len(w.Walkers) || r.Level.Enemies != w.Level.Enemies {
+			t.Errorf("nível %q retomado como %q: tempo %d, %d inimigos, %d guardas; queria tempo %d, %d inimigos, %d guardas",
+				w.Level.Title, r.Level.Title, r.Level.GameTime, r.Level.Enemies, len(r.Walkers), w.Level.GameTime, w.Level.Enemies, len(w.Walkers))
+		}
+		w.State = Won
+		won := ResumeWorld(w.Seed, w.Progress(), campaign)
+		w.Step(Input{Advance: true})
+		if won.Level.Title != w.Level.Title || won.Level.Size() != w.Level.Size() || won.Round != w.Round {
+			t.Errorf("salvo na vitória em %q, continuou em %q (grid %d, rodada %d) em vez de %q (grid %d, rodada %d)",
+				want, won.Level.Title, won.Level.Size(), won.Round, w.Level.Title, w.Level.Size(), w.Round)
+		}
+		r.State = Won
+		r.Step(Input{Advance: true})
+		if r.Level.Title != w.Level.Title || r.Level.Size() != w.Level.Size() || r.Level.Difficulty != w.Level.Difficulty {
+			t.Errorf("depois de %q, a partida retomada foi para %q (grid %d, dificuldade %d) em vez de %q (grid %d, dificuldade %d)",
+				want, r.Level.Title, r.Level.Size(), r.Level.Difficulty, w.Level.Title, w.Level.Size(), w.Level.Difficulty)
+		}
+	}
+}
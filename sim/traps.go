@@ -0,0 +1,191 @@
+package sim
+
+import (
+	"image/color"
+	"math/rand"
+	"sort"
+)
+
+// TrapType é o tipo de uma armadilha. O tipo zero é o buraco, então
+// tabuleiros sem Board.TrapTypes (e códigos antigos) continuam iguais.
+type TrapType int
+
+const (
+	Pit        TrapType = iota // Volta para a entrada
+	Spike                      // Custa uma vida, mas o jogador fica onde está
+	Teleporter                 // Leva para o teletransporte par
+	Sticky                     // Prende o jogador por alguns movimentos
+	Crumbling                  // Aguenta uma passagem; na segunda vira buraco
+	Alarm                      // Põe os inimigos em modo killer, até o limite
+)
+
+// TrapTypes é o número de tipos de armadilha
+const TrapTypes = 6
+
+// stickyMoves é quantos movimentos o jogador perde preso numa armadilha Sticky
+const stickyMoves = 3
+
+// TrapInfo descreve um tipo de armadilha no registro trapInfos
+type TrapInfo struct {
+	Name    string
+	Color   color.RGBA // Cor na fase de memorização
+	Weights [4]int     // Peso no gerador em cada dificuldade (índices 1 a 3)
+	trigger func(w *World, node int)
+}
+
+// trapInfos é o registro dos tipos de armadilha
+var trapInfos = [TrapTypes]TrapInfo{
+	Pit:        {"Buraco", color.RGBA{255, 0, 0, 255}, [4]int{1: 1, 2: 6, 3: 4}, (*World).triggerPit},
+	Spike:      {"Espinhos", color.RGBA{255, 140, 0, 255}, [4]int{2: 2, 3: 2}, (*World).triggerSpike},
+	Teleporter: {"Teletransporte", color.RGBA{160, 60, 220, 255}, [4]int{2: 1, 3: 2}, (*World).triggerTeleporter},
+	Sticky:     {"Cola", color.RGBA{200, 180, 0, 255}, [4]int{2: 2, 3: 2}, (*World).triggerSticky},
+	Crumbling:  {"Piso frágil", color.RGBA{170, 110, 60, 255}, [4]int{2: 1, 3: 2}, (*World).triggerCrumbling},
+	Alarm:      {"Alarme", color.RGBA{0, 120, 255, 255}, [4]int{2: 1, 3: 1}, (*World).triggerAlarm},
+}
+
+// Info devolve a descrição do tipo t
+func (t TrapType) Info() TrapInfo {
+	if !t.Valid() {
+		return trapInfos[Pit]
+	}
+	return trapInfos[t]
+}
+
+// Valid diz se t é um dos tipos conhecidos
+func (t TrapType) Valid() bool {
+	return t >= 0 && t < TrapTypes
+}
+
+// TrapAt devolve o tipo da armadilha no nó node
+func (b Board) TrapAt(node int) TrapType {
+	return b.TrapTypes[node]
+}
+
+// TeleporterPair devolve o teletransporte ligado ao do nó node. Os
+// teletransportes são ligados dois a dois na ordem dos nós; um que sobre
+// sozinho não tem par e funciona como buraco.
+func (b Board) TeleporterPair(node int) (int, bool) {
+	var teleporters []int
+	for n, t := range b.TrapTypes {
+		if t == Teleporter && b.Traps[n] {
+			teleporters = append(teleporters, n)
+		}
+	}
+	sort.Ints(teleporters)
+	for i, n := range teleporters {
+		if n == node && i^1 < len(teleporters) {
+			return teleporters[i^1], true
+		}
+	}
+	return 0, false
+}
+
+// generateTrapTypes sorteia o tipo de cada armadilha com os pesos da
+// dificuldade. Onde só há buracos nada é sorteado, para não mexer no rng.
+func generateTrapTypes(rng *rand.Rand, traps map[int]bool, dificulty int) map[int]TrapType {
+	dificulty = difficultyIndex(dificulty)
+	total := 0
+	for _, info := range trapInfos {
+		total += info.Weights[dificulty]
+	}
+	if total == trapInfos[Pit].Weights[dificulty] {
+		return nil
+	}
+
+	nodes := make([]int, 0, len(traps))
+	for node := range traps {
+		nodes = append(nodes, node)
+	}
+	sort.Ints(nodes)
+
+	types := make(map[int]TrapType)
+	var lastTeleporter, teleporters int
+	for _, node := range nodes {
+		r := rng.Intn(total)
+		t := Pit
+		for r >= trapInfos[t].Weights[dificulty] {
+			r -= trapInfos[t].Weights[dificulty]
+			t++
+		}
+		if t == Pit {
+			continue
+		}
+		types[node] = t
+		if t == Teleporter {
+			lastTeleporter = node
+			teleporters++
+		}
+	}
+	// Teletransportes andam em pares
+	if teleporters%2 == 1 {
+		delete(types, lastTeleporter)
+	}
+	if len(types) == 0 {
+		return nil
+	}
+	return types
+}
+
+// stepOnTrap aplica o efeito da armadilha do nó node, onde o jogador acabou
+// de pisar
+func (w *World) stepOnTrap(node int) {
+	w.Level.TrapAt(node).Info().trigger(w, node)
+}
+
+// triggerPit manda o jogador de volta para a entrada
+func (w *World) triggerPit(node int) {
+	w.PlayerX = w.Level.Start
+	w.PlayerY = -1
+	w.State = Playing
+	w.ShowTraps = false
+	w.Aiming = false
+	w.Message = ""
+	w.FallenTraps[node] = true
+}
+
+// triggerSpike tira uma vida, sem tirar o jogador do lugar
+func (w *World) triggerSpike(node int) {
+	w.FallenTraps[node] = true
+	w.Lives--
+	if w.Lives <= 0 {
+		w.lose("Espinhos! Pressione R para tentar novamente")
+	}
+}
+
+// triggerTeleporter leva o jogador para o teletransporte par
+func (w *World) triggerTeleporter(node int) {
+	pair, ok := w.Level.TeleporterPair(node)
+	if !ok {
+		w.triggerPit(node)
+		return
+	}
+	w.FallenTraps[node] = true
+	w.FallenTraps[pair] = true
+	w.PlayerX, w.PlayerY = pair%w.Level.Width, pair/w.Level.Width
+}
+
+// triggerSticky prende o jogador por alguns movimentos
+func (w *World) triggerSticky(node int) {
+	w.FallenTraps[node] = true
+	w.Stuck = stickyMoves
+}
+
+// triggerCrumbling racha o piso na primeira passagem e cai na segunda
+func (w *World) triggerCrumbling(node int) {
+	if w.Cracked[node] {
+		w.triggerPit(node)
+		return
+	}
+	w.Cracked[node] = true
+}
+
+// triggerAlarm põe os inimigos vivos em modo killer, até o limite de
+// inimigos nesse modo ao mesmo tempo
+func (w *World) triggerAlarm(node int) {
+	w.FallenTraps[node] = true
+	for _, e := range w.Enemies {
+		if e.Alive {
+			w.setKillerMode(e, true)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package sim
+
+import (
+	"testing"
+
+	"example/tesourim/utils"
+)
+
+// TestTraps pisa em cada tipo de armadilha, posta no nó 6 (acima da
+// entrada) do tabuleiro de testWorld
+func TestTraps(t *testing.T) {
+	tests := []struct {
+		name   string
+		trap   TrapType
+		moves  [][2]int
+		x, y   int
+		lives  int
+		fallen bool
+	}{
+		{"buraco volta à entrada", Pit, [][2]int{up, up}, 0, -1, 2, true},
+		{"espinhos tiram uma vida", Spike, [][2]int{up, up}, 0, 1, 1, true},
+		{"teletransporte leva ao par", Teleporter, [][2]int{up, up}, 0, 5, 2, true},
+		{"cola prende por três movimentos", Sticky, [][2]int{up, up, up, up, up}, 0, 1, 2, true},
+		{"cola solta depois", Sticky, [][2]int{up, up, up, up, up, up}, 0, 2, 2, true},
+		{"piso frágil aguenta uma passagem", Crumbling, [][2]int{up, up, up}, 0, 2, 2, false},
+		{"piso frágil cai na segunda", Crumbling, [][2]int{up, up, up, down}, 0, -1, 2, true},
+		{"alarme não tira do lugar", Alarm, [][2]int{up, up}, 0, 1, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testWorld(2)
+			w.Level.Traps[6] = true
+			w.Level.TrapTypes[6] = tt.trap
+			if tt.trap == Teleporter {
+				w.Level.Traps[30] = true
+				w.Level.TrapTypes[30] = Teleporter
+			}
+			play(w, tt.moves...)
+			if w.PlayerX != tt.x || w.PlayerY != tt.y {
+				t.Errorf("jogador em (%d, %d), queria (%d, %d)", w.PlayerX, w.PlayerY, tt.x, tt.y)
+			}
+			if w.Lives != tt.lives {
+				t.Errorf("%d vidas, queria %d", w.Lives, tt.lives)
+			}
+			if w.FallenTraps[6] != tt.fallen {
+				t.Errorf("armadilha caída = %v, queria %v", w.FallenTraps[6], tt.fallen)
+			}
+		})
+	}
+}
+
+// TestTeleporterPair confere que os teletransportes se ligam dois a dois na
+// ordem dos nós e que o que sobra não tem par
+func TestTeleporterPair(t *testing.T) {
+	b := Board{
+		Traps:     map[int]bool{3: true, 9: true, 12: true, 20: true, 25: true},
+		TrapTypes: map[int]TrapType{3: Teleporter, 9: Teleporter, 12: Spike, 20: Teleporter, 25: Teleporter},
+	}
+	for node, want := range map[int]int{3: 9, 9: 3, 20: 25, 25: 20} {
+		if got, ok := b.TeleporterPair(node); !ok || got != want {
+			t.Errorf("par de %d: %d (%v), queria %d", node, got, ok, want)
+		}
+	}
+	b.Traps[25] = false
+	if got, ok := b.TeleporterPair(20); ok {
+		t.Errorf("teletransporte sozinho com par %d", got)
+	}
+}
+
+// TestTrapTypesClampDifficulty sorteia tipos de armadilha com dificuldades
+// fora de 1 a 3, que não podem sair da tabela de pesos
+func TestTrapTypesClampDifficulty(t *testing.T) {
+	traps := map[int]bool{3: true, 9: true, 12: true}
+	for _, dificulty := range []int{-1, 0, 4} {
+		for node, tt := range generateTrapTypes(utils.NewRand(1), traps, dificulty) {
+			if !traps[node] || !tt.Valid() {
+				t.Errorf("dificuldade %d: nó %d com tipo %d", dificulty, node, tt)
+			}
+		}
+	}
+}
+
+// TestAlarmKillerLimit dispara o alarme com mais inimigos que o limite de
+// killers e confere que a contagem volta a zero quando todos saem do modo
+func TestAlarmKillerLimit(t *testing.T) {
+	w := NewWorld(1)
+	l := NewLevel(generate(t, 1, SquareBoard(7), 2))
+	l.Enemies = w.maxKillers + 2
+	w.LoadLevel(l)
+	w.triggerAlarm(w.Level.Node(0, 0))
+
+	killers := 0
+	for _, e := range w.Enemies {
+		if e.KillerMode {
+			killers++
+		}
+	}
+	if killers != w.maxKillers || w.killersCount != w.maxKillers {
+		t.Errorf("%d inimigos em modo killer, contagem %d; queria %d", killers, w.killersCount, w.maxKillers)
+	}
+	for _, e := range w.Enemies {
+		w.setKillerMode(e, false)
+	}
+	if w.killersCount != 0 {
+		t.Errorf("contagem %d depois que todos saíram do modo killer", w.killersCount)
+	}
+}
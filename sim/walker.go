@@ -0,0 +1,199 @@
+package sim
+
+import (
+	"math"
+	"strings"
+
+	"example/tesourim/utils"
+)
+
+// WalkerKind é o tipo de um inimigo que anda dentro do tabuleiro
+type WalkerKind int
+
+const (
+	Guard WalkerKind = iota // Desvia das armadilhas
+	Scout                   // Pisa nas armadilhas de propósito e as revela
+)
+
+// WalkerKinds é o número de tipos de inimigo do tabuleiro
+const WalkerKinds = 2
+
+var walkerNames = [WalkerKinds]string{"Guarda", "Batedor"}
+
+// Name devolve o nome do tipo k
+func (k WalkerKind) Name() string {
+	if k < 0 || k >= WalkerKinds {
+		k = Guard
+	}
+	return walkerNames[k]
+}
+
+// WalkerSpec descreve, no nível, um inimigo do tabuleiro
+type WalkerSpec struct {
+	Kind  WalkerKind
+	Route []int // Nós da patrulha, percorridos em ciclo; ele começa no primeiro
+}
+
+// walkerTicks é quantos ticks um inimigo do tabuleiro leva para andar uma
+// célula em cada dificuldade
+var walkerTicks = [4]int{1: 45, 2: 35, 3: 25}
+
+const (
+	walkerSight = 4.0    // Até onde o inimigo vê o jogador, em larguras de célula
+	walkerRest  = 2 * 60 // Ticks parado depois de pegar o jogador
+)
+
+// Walker é um inimigo que anda de célula em célula pelo grafo do tabuleiro:
+// patrulha a rota dele e, quando vê o jogador, persegue com A*. Encostar no
+// jogador custa uma vida.
+type Walker struct {
+	WalkerSpec
+	Node    int  // Célula atual
+	Prev    int  // Célula de onde veio, para desenhar o passo
+	Chasing bool // Se está perseguindo o jogador
+	next    int  // Índice em Route do próximo ponto da patrulha
+	timer   int  // Ticks desde o último passo
+	rest    int  // Ticks que ainda fica parado
+}
+
+// createWalkers põe os inimigos do tabuleiro no começo das suas rotas
+func (w *World) createWalkers() []*Walker {
+	var walkers []*Walker
+	for _, spec := range w.Level.Walkers {
+		if len(spec.Route) == 0 {
+			continue
+		}
+		k := &Walker{WalkerSpec: spec}
+		k.respawn()
+		walkers = append(walkers, k)
+	}
+	return walkers
+}
+
+// respawn volta o inimigo para o começo da rota
+func (k *Walker) respawn() {
+	k.Node, k.Prev = k.Route[0], k.Route[0]
+	k.next = 1 % len(k.Route)
+	k.timer, k.Chasing = 0, false
+}
+
+// Position devolve o centro do inimigo no plano de Board.Center, deslizando
+// entre a célula anterior e a atual
+func (k *Walker) Position(w *World) (float64, float64) {
+	b := w.Level.Board
+	x1, y1 := b.Center(k.Prev%b.Width, k.Prev/b.Width)
+	x2, y2 := b.Center(k.Node%b.Width, k.Node/b.Width)
+	t := min(float64(k.timer)/float64(walkerTicks[difficultyIndex(b.Difficulty)]), 1)
+	return x1 + (x2-x1)*t, y1 + (y2-y1)*t
+}
+
+// updateWalkers anda com os inimigos do tabuleiro e tira uma vida de quem
+// for pego
+func (w *World) updateWalkers() {
+	playerNode := -1
+	if w.Level.Inside(w.PlayerX, w.PlayerY) {
+		playerNode = w.Level.Node(w.PlayerX, w.PlayerY)
+	}
+	for _, k := range w.Walkers {
+		if k.rest > 0 {
+			k.rest--
+			continue
+		}
+		k.timer++
+		if k.timer >= walkerTicks[difficultyIndex(w.Level.Difficulty)] {
+			k.timer = 0
+			k.step(w, playerNode)
+		}
+		if k.Node == playerNode {
+			w.Lives--
+			k.respawn()
+			k.rest = walkerRest
+			if w.Lives <= 0 {
+				w.lose("Pego por um " + strings.ToLower(k.Kind.Name()) + "! Pressione R para tentar novamente")
+				return
+			}
+		}
+	}
+}
+
+// step anda uma célula: atrás do jogador, se ele estiver à vista, ou para o
+// próximo ponto da patrulha
+func (k *Walker) step(w *World, playerNode int) {
+	b := w.Level.Board
+	k.Chasing = playerNode >= 0 && b.LineOfSight(k.Node, playerNode)
+	goal := k.Route[k.next]
+	if k.Chasing {
+		goal = playerNode
+	}
+
+	// O batedor não tem medo de armadilhas
+	var blocked map[int]bool
+	if k.Kind == Guard {
+		blocked = b.Traps
+	}
+	path := utils.AStar(w.graph, blocked, k.Node, goal, func(node int) int {
+		return b.minSteps(node, goal)
+	})
+
+	k.Prev = k.Node
+	if len(path) >= 2 {
+		k.Node = path[1]
+		if b.Traps[k.Node] {
+			w.FallenTraps[k.Node] = true
+		}
+	}
+	// Chegou ao ponto da patrulha, ou não há caminho até ele
+	if !k.Chasing && (k.Node == goal || path == nil) {
+		k.next = (k.next + 1) % len(k.Route)
+	}
+}
+
+// minSteps é o menor número de passos possível entre os nós a e c com a
+// regra de movimento do tabuleiro, a heurística do A* dos inimigos
+func (b Board) minSteps(a, c int) int {
+	x1, y1, x2, y2 := a%b.Width, a/b.Width, c%b.Width, c/b.Width
+	if b.Hex {
+		return utils.HexDistance(x1, y1, x2, y2)
+	}
+	dx, dy := abs(x2-x1), abs(y2-y1)
+	switch b.Movement {
+	case utils.Orthogonal:
+		return dx + dy
+	case utils.Knight:
+		return (max(dx, dy) + 1) / 2
+	}
+	return max(dx, dy)
+}
+
+// LineOfSight diz se dá para ver o nó c a partir do nó a: estão a até
+// walkerSight de distância e a reta entre os dois não passa por células
+// vazias nem atravessa paredes
+func (b Board) LineOfSight(a, c int) bool {
+	x1, y1 := b.Center(a%b.Width, a/b.Width)
+	x2, y2 := b.Center(c%b.Width, c/b.Width)
+	dist := math.Hypot(x2-x1, y2-y1)
+	if dist > walkerSight {
+		return false
+	}
+	samples := int(dist*4) + 1
+	px, py := a%b.Width, a/b.Width
+	for i := 1; i <= samples; i++ {
+		t := float64(i) / float64(samples)
+		x, y := b.CellAt(x1+(x2-x1)*t, y1+(y2-y1)*t)
+		if !b.Inside(x, y) {
+			return false
+		}
+		if (x != px || y != py) && b.Blocked(px, py, x, y) {
+			return false
+		}
+		px, py = x, y
+	}
+	return true
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,47 @@
+package sim
+
+import "testing"
+
+// walkerWorld é testWorld sem os inimigos de fora e com um guarda na rota
+// route
+func walkerWorld(route ...int) *World {
+	w := testWorld(3)
+	w.Enemies = nil
+	w.Level.Walkers = []WalkerSpec{{Kind: Guard, Route: route}}
+	w.Walkers = w.createWalkers()
+	return w
+}
+
+// TestWalkerPatrol anda com um guarda longe do jogador, que fica na entrada
+// sem ser visto
+func TestWalkerPatrol(t *testing.T) {
+	w := walkerWorld(24, 28)
+	k := w.Walkers[0]
+	// Os pontos da rota ficam a quatro passos um do outro
+	for _, want := range []int{28, 24, 28} {
+		for range 4 * walkerTicks[1] {
+			w.Step(Input{})
+		}
+		if k.Node != want || k.Chasing {
+			t.Fatalf("guarda no nó %d (perseguindo = %v), queria %d", k.Node, k.Chasing, want)
+		}
+	}
+}
+
+// TestWalkerCatch põe o jogador à vista do guarda, que o persegue, desvia dos
+// espinhos do nó 7 e tira uma vida ao pegá-lo
+func TestWalkerCatch(t *testing.T) {
+	w := walkerWorld(18)
+	play(w, up)
+	k := w.Walkers[0]
+	// Do nó 18 até o jogador, no nó 0, são três passos
+	for range 3 * walkerTicks[1] {
+		w.Step(Input{})
+	}
+	if k.Node != 18 || k.rest == 0 {
+		t.Errorf("guarda no nó %d, parado por %d ticks; queria de volta no 18, parado", k.Node, k.rest)
+	}
+	if w.Lives != 2 || w.FallenTraps[7] {
+		t.Errorf("%d vidas, espinhos caídos = %v; queria 2 vidas e os espinhos de pé", w.Lives, w.FallenTraps[7])
+	}
+}
@@ -0,0 +1,132 @@
+package sim
+
+import (
+	"image/color"
+	"math"
+)
+
+// Weapon descreve como um inimigo atira: quantos projéteis por tiro, para
+// onde e como cada um anda e aparece
+type Weapon struct {
+	Count    int        // Projéteis por tiro
+	Spread   float64    // Ângulo entre projéteis vizinhos, em radianos
+	Aimed    bool       // Mira no centro da célula do jogador em vez de atirar reto
+	Speed    float64    // Células por tick
+	Radius   float64    // Raio, em larguras de célula
+	Color    color.RGBA // Cor antes de ser refletido
+	TurnRate float64    // Quanto o projétil gira por tick atrás do jogador, em radianos (0 = não persegue)
+	Life     int        // Ticks até o projétil sumir (0 = só some ao sair da arena)
+	Cooldown int        // Ticks entre um tiro e outro
+}
+
+// WeaponKind é uma das armas dos inimigos
+type WeaponKind int
+
+const (
+	Straight WeaponKind = iota // Um projétil reto para dentro do tabuleiro, a arma original
+	Triple                     // Três projéteis num leque estreito
+	Fan                        // Cinco projéteis num leque aberto, mais lentos
+	Aimed                      // Um projétil rápido mirado na célula do jogador
+	Homing                     // Um projétil lento e grande que persegue o jogador
+)
+
+// WeaponKinds é o número de armas
+const WeaponKinds = 5
+
+// weaponInfos é o registro das armas: nome e dados
+var weaponInfos = [WeaponKinds]struct {
+	name   string
+	weapon Weapon
+}{
+	Straight: {"Reto", Weapon{Count: 1, Speed: BulletSpeed, Radius: 0.15, Color: color.RGBA{255, 255, 0, 255}, Cooldown: 90}},
+	Triple:   {"Triplo", Weapon{Count: 3, Spread: math.Pi / 12, Speed: BulletSpeed, Radius: 0.12, Color: color.RGBA{255, 160, 0, 255}, Cooldown: 120}},
+	Fan:      {"Leque", Weapon{Count: 5, Spread: math.Pi / 8, Speed: 0.12, Radius: 0.12, Color: color.RGBA{255, 90, 200, 255}, Cooldown: 150}},
+	Aimed:    {"Mirado", Weapon{Count: 1, Aimed: true, Speed: 0.22, Radius: 0.12, Color: color.RGBA{255, 60, 60, 255}, Cooldown: 90}},
+	Homing:   {"Teleguiado", Weapon{Count: 1, Aimed: true, Speed: 0.07, Radius: 0.25, Color: color.RGBA{170, 255, 60, 255}, TurnRate: 0.03, Life: 6 * 60, Cooldown: 180}},
+}
+
+// Name devolve o nome da arma k
+func (k WeaponKind) Name() string {
+	if !k.Valid() {
+		k = Straight
+	}
+	return weaponInfos[k].name
+}
+
+// Valid diz se k é uma das armas conhecidas
+func (k WeaponKind) Valid() bool {
+	return k >= 0 && k < WeaponKinds
+}
+
+// Weapon devolve os dados da arma k
+func (k WeaponKind) Weapon() Weapon {
+	if !k.Valid() {
+		k = Straight
+	}
+	return weaponInfos[k].weapon
+}
+
+// shoot devolve os projéteis de um tiro de e com a arma dele. Os projéteis
+// saem do inimigo na direção do lado ou, com Weapon.Aimed, na do jogador, e
+// os do leque se abrem em volta dessa direção.
+func (e *Enemy) shoot(w *World, active bool) []Bullet {
+	g := e.Weapon
+	x, y := e.Position(w.Level.Board)
+	dx, dy := e.Side.inward()
+	if g.Aimed {
+		px, py := w.Level.Center(w.PlayerX, w.PlayerY)
+		if dist := math.Hypot(px-x, py-y); dist > 0 {
+			dx, dy = (px-x)/dist, (py-y)/dist
+		}
+	}
+
+	bullets := make([]Bullet, max(g.Count, 1))
+	for i := range bullets {
+		vx, vy := dx, dy
+		if offset := (float64(i) - float64(len(bullets)-1)/2) * g.Spread; offset != 0 {
+			vx, vy = rotate(dx, dy, offset)
+		}
+		bullets[i] = Bullet{
+			X: x, Y: y,
+			DX: vx * g.Speed, DY: vy * g.Speed,
+			Active: active, Owner: e,
+			Radius: g.Radius, Color: g.Color,
+			TurnRate: g.TurnRate, Life: g.Life,
+		}
+	}
+	return bullets
+}
+
+// move anda com o projétil um tick. Enquanto não é refletido, um projétil
+// teleguiado gira até TurnRate em direção ao jogador.
+func (b *Bullet) move(w *World) {
+	if b.TurnRate > 0 && !b.Reflected {
+		px, py := w.Level.Center(w.PlayerX, w.PlayerY)
+		// Diferença entre os ângulos, levada para [-π, π]
+		turn := math.Remainder(math.Atan2(py-b.Y, px-b.X)-math.Atan2(b.DY, b.DX), 2*math.Pi)
+		b.DX, b.DY = rotate(b.DX, b.DY, min(max(turn, -b.TurnRate), b.TurnRate))
+	}
+	b.X += b.DX
+	b.Y += b.DY
+	if b.Life > 0 {
+		if b.Life--; b.Life == 0 {
+			b.Active = false
+		}
+	}
+}
+
+// hits diz se o projétil acerta quem está na célula (x, y): está dentro da
+// célula ou, se for grande, encosta no centro dela
+func (b *Bullet) hits(w *World, x, y int) bool {
+	if bx, by := w.Level.CellAt(b.X, b.Y); bx == x && by == y {
+		return true
+	}
+	cx, cy := w.Level.Center(x, y)
+	return math.Hypot(b.X-cx, b.Y-cy) < b.Radius
+}
+
+// rotate gira o vetor (x, y) em angle radianos
+func rotate(x, y, angle float64) (float64, float64) {
+	sin, cos := math.Sincos(angle)
+	return x*cos - y*sin, x*sin + y*cos
+}
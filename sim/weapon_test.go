@@ -0,0 +1,59 @@
+package sim
+
+import (
+	"math"
+	"testing"
+)
+
+// TestShoot atira com cada arma de um inimigo em cima, no meio da linha, e
+// confere o número de projéteis, a velocidade e a direção do tiro
+func TestShoot(t *testing.T) {
+	w := testWorld(2)
+	for k := WeaponKind(0); k < WeaponKinds; k++ {
+		t.Run(k.Name(), func(t *testing.T) {
+			g := k.Weapon()
+			e := &Enemy{Pos: 2.5, Weapon: g}
+			bullets := e.shoot(w, true)
+			if len(bullets) != g.Count {
+				t.Fatalf("%d projéteis, queria %d", len(bullets), g.Count)
+			}
+			var sumX, sumY float64
+			for _, b := range bullets {
+				if speed := math.Hypot(b.DX, b.DY); math.Abs(speed-g.Speed) > 1e-9 {
+					t.Errorf("velocidade %.3f, queria %.3f", speed, g.Speed)
+				}
+				sumX, sumY = sumX+b.DX, sumY+b.DY
+			}
+
+			// O leque se abre em volta da direção do tiro: para dentro do
+			// tabuleiro ou, mirado, para o jogador em (0, 6)
+			wantX, wantY := 0.0, 1.0
+			if g.Aimed {
+				wantX, wantY = -2.5, 6-EnemyY
+			}
+			angle := math.Atan2(sumY, sumX) - math.Atan2(wantY, wantX)
+			if math.Abs(angle) > 1e-9 {
+				t.Errorf("tiro desviado %.3f radianos", angle)
+			}
+		})
+	}
+}
+
+// TestHoming confere que o teleguiado gira no máximo TurnRate por tick atrás
+// do jogador e some depois de Life ticks
+func TestHoming(t *testing.T) {
+	w := testWorld(2)
+	g := Homing.Weapon()
+	b := Bullet{X: 5, Y: 0, DX: g.Speed, Active: true, TurnRate: g.TurnRate, Life: g.Life}
+	for tick := 1; tick <= g.Life; tick++ {
+		before := math.Atan2(b.DY, b.DX)
+		b.move(w)
+		turn := math.Abs(math.Remainder(math.Atan2(b.DY, b.DX)-before, 2*math.Pi))
+		if turn > g.TurnRate+1e-9 {
+			t.Fatalf("tick %d: girou %.3f radianos, queria até %.3f", tick, turn, g.TurnRate)
+		}
+		if b.Active != (tick < g.Life) {
+			t.Fatalf("tick %d: ativo = %v", tick, b.Active)
+		}
+	}
+}
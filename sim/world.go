@@ -0,0 +1,511 @@
+// Package sim contém as regras do Tesourim sem nenhuma dependência do Ebiten.
+// O World avança um tick por vez a partir de um Input explícito, o que permite
+// rodar o jogo sem tela (testes, bots, replays).
+package sim
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"example/tesourim/utils"
+)
+
+const MaxGridSize = 13
+
+// State representa a fase atual do jogo
+type State int
+
+const (
+	Playing State = iota
+	Won
+	Lost
+	Memorizing
+	Paused
+)
+
+// Input descreve as ações do jogador em um único tick
+type Input struct {
+	MoveX, MoveY int    // Direção do movimento (ou da mira, quando mirando)
+	Aim          bool   // Entra/sai do modo de mira
+	Throw        bool   // Lança a pedra (ou pula a memorização)
+	Reflect      bool   // Tenta refletir um projétil próximo
+	Restart      bool   // Volta ao início (ou reinicia após derrota)
+	Advance      bool   // Avança para o próximo nível após vitória
+	Load         *Board // Troca o nível atual por este tabuleiro (código compartilhado)
+	Pause        bool   // Pausa o jogo, ou retoma se já estiver pausado
+	Flag         bool   // Põe ou tira uma bandeira na célula (FlagX, FlagY)
+	FlagX, FlagY int
+	Torch        bool // Acende uma tocha no modo Board.Dark
+}
+
+// Rock representa uma pedra lançada
+type Rock struct {
+	X, Y     float64 // Posição no plano de Board.Center
+	TargetX  int
+	TargetY  int
+	Active   bool
+	Revealed map[int]bool // Nós revelados por esta pedra
+}
+
+// World guarda todo o estado de uma partida
+type World struct {
+	Seed        int64 // Seed que gerou a partida
+	Level       Level // Nível atual
+	FallenTraps map[int]bool
+	Cracked     map[int]bool // Pisos frágeis que já racharam nesta tentativa
+	Visited     map[int]bool // Células seguras conhecidas, que mostram as dicas no modo Board.Hints
+	Flags       map[int]bool // Células que o jogador marcou como suspeitas
+	Enemies     []*Enemy
+	Walkers     []*Walker // Inimigos que andam dentro do tabuleiro
+	Rocks       []Rock
+	Glows       []Glow // Luzes deixadas pelas pedras no modo Board.Dark
+
+	PlayerX      int    // Posição X no grid
+	PlayerY      int    // Posição Y no grid (-1 = fora do grid)
+	State        State  // Fase atual
+	Message      string // Mensagem de vitória/derrota
+	Timer        int    // Timer da fase de memorização
+	ShowTraps    bool   // Se as armadilhas e o tesouro estão visíveis
+	GameTimer    int    // Timer da fase de jogo
+	Lives        int    // Vidas restantes
+	RocksLeft    int    // Pedras disponíveis
+	AimX, AimY   int    // Posição da mira
+	Aiming       bool   // Se o jogador está mirando
+	EndGame      bool   // Se o último nível foi vencido
+	EndGameTimer int    // Contagem regressiva do fim de jogo
+	Over         bool   // Se o jogo terminou e deve ser fechado
+	Round        int    // Quantos tabuleiros já começaram nesta partida (1 = o primeiro)
+	Stuck        int    // Movimentos que o jogador ainda perde preso numa armadilha
+	TorchesLeft  int    // Tochas disponíveis
+	TorchTimer   int    // Ticks até a tocha acesa apagar
+
+	Campaign     []Level // Níveis feitos à mão, jogados antes dos procedurais
+	CampaignStep int     // Quantos níveis da campanha já começaram
+	inCampaign   bool    // Se o nível atual é o nível CampaignStep da campanha
+
+	restart      bool
+	resumeState  State         // Fase a retomar quando o jogo sair da pausa
+	resumeMsg    string        // Mensagem a retomar quando o jogo sair da pausa
+	killersCount int           // Número atual de inimigos em modo killer
+	maxKillers   int           // Máximo de inimigos em modo killer simultaneamente
+	graph        map[int][]int // Grafo do tabuleiro do nível atual, montado uma vez em enterLevel
+	rng          *rand.Rand
+}
+
+// NewWorld cria uma partida no primeiro nível. Todo sorteio da partida vem
+// da seed, então duas partidas com a mesma seed e as mesmas entradas são iguais.
+func NewWorld(seed int64) *World {
+	w := newWorld(seed, utils.NewRand(seed))
+	w.enterLevel(NewLevel(w.generateSquare(SquareBoard(6), 1)))
+	return w
+}
+
+// NewCampaignWorld cria uma partida que começa pelos níveis de campaign, em
+// ordem, e depois segue a progressão normal a partir do último deles
+func NewCampaignWorld(seed int64, campaign []Level) *World {
+	if len(campaign) == 0 {
+		return NewWorld(seed)
+	}
+	w := newWorld(seed, utils.NewRand(seed))
+	w.Campaign = campaign
+	w.enterLevel(w.nextLevel())
+	return w
+}
+
+func newWorld(seed int64, rng *rand.Rand) *World {
+	return &World{
+		Seed:       seed,
+		maxKillers: 3,
+		rng:        rng,
+	}
+}
+
+// Board devolve o tabuleiro do nível atual
+func (w *World) Board() Board {
+	return w.Level.Board
+}
+
+// Graph devolve o grafo de vizinhança do tabuleiro atual. É montado uma vez
+// por nível e não deve ser alterado.
+func (w *World) Graph() map[int][]int {
+	return w.graph
+}
+
+// AdjacentTraps devolve o número de armadilhas vizinhas de node no tabuleiro
+// atual, a dica mostrada no modo Hints
+func (w *World) AdjacentTraps(node int) int {
+	return utils.AdjacentTraps(w.graph, w.Level.Traps, node)
+}
+
+// OptimalRoute devolve um menor caminho seguro da entrada até o tesouro do
+// tabuleiro atual
+func (w *World) OptimalRoute() []int {
+	return utils.ShortestPath(w.graph, w.Level.Traps, w.Level.Starts(), w.Level.Treasure)
+}
+
+// LoadBoard troca o nível atual pelo tabuleiro b e recomeça a memorização
+func (w *World) LoadBoard(b Board) {
+	w.LoadLevel(NewLevel(b))
+}
+
+// LoadLevel troca o nível atual por l e recomeça a memorização
+func (w *World) LoadLevel(l Level) {
+	w.EndGame = false
+	w.inCampaign = false
+	w.enterLevel(l)
+}
+
+// enterLevel começa um novo nível
+func (w *World) enterLevel(l Level) {
+	w.Level = l
+	w.graph = l.Graph()
+	w.Round++
+	w.reset()
+}
+
+// reset começa uma nova tentativa do nível atual: jogador na entrada, timers,
+// vidas, pedras e inimigos como no começo, na fase de memorização
+func (w *World) reset() {
+	w.FallenTraps = make(map[int]bool)
+	w.Cracked = make(map[int]bool)
+	w.Visited = make(map[int]bool)
+	w.Flags = make(map[int]bool)
+	for _, start := range w.Level.Starts() {
+		if !w.Level.Traps[start] {
+			w.Visited[start] = true
+		}
+	}
+	w.Stuck = 0
+	w.Rocks = make([]Rock, 0)
+	w.Glows = nil
+	w.killersCount = 0
+	w.Enemies = w.createEnemies()
+	w.Walkers = w.createWalkers()
+	w.PlayerX = w.Level.Start
+	w.PlayerY = -1
+	w.State = Memorizing
+	w.Timer = w.Level.MemorizeTime
+	w.ShowTraps = true
+	w.GameTimer = w.Level.GameTime
+	w.Lives = w.Level.Lives
+	w.RocksLeft = w.Level.Rocks
+	w.TorchesLeft = w.Level.Torches
+	w.TorchTimer = 0
+	w.Aiming = false
+	w.restart = false
+	w.Message = fmt.Sprintf("Memorize em %d segundos!", w.Timer/60)
+}
+
+// Step avança a simulação em um tick (1/60 s)
+func (w *World) Step(in Input) {
+	if in.Load != nil {
+		w.LoadBoard(*in.Load)
+		return
+	}
+
+	// Pausado, nada anda: só dá para retomar ou reiniciar o nível
+	if in.Pause {
+		w.togglePause()
+		return
+	}
+	if w.State == Paused {
+		if in.Restart {
+			w.reset()
+		}
+		return
+	}
+
+	if w.EndGame {
+		w.Message = "Parabéns! você venceu o jogo!"
+		if w.EndGameTimer == 0 {
+			w.EndGameTimer = 180 // 3 segundos a 60 FPS
+		}
+		w.EndGameTimer--
+		if w.EndGameTimer <= 0 {
+			w.Over = true
+			return
+		}
+	}
+
+	// Fase de memorização
+	if w.State == Memorizing {
+		w.Timer--
+		if w.Timer <= 0 || in.Throw {
+			w.State = Playing
+			w.ShowTraps = false
+			w.Message = ""
+		} else {
+			w.Message = fmt.Sprintf("Memorize em %d segundos!   Pressione SPACE para avançar", w.Timer/60)
+		}
+		return
+	}
+
+	// Só permite movimento enquanto o jogo estiver em andamento
+	if w.State == Playing {
+		// Atualiza o timer do jogo
+		w.GameTimer--
+		if w.GameTimer <= 0 {
+			w.lose("Tempo esgotado! Pressione R para tentar novamente")
+			return
+		}
+
+		if w.Lives == 0 {
+			w.lose("Atingido! Pressione R para reiniciar")
+			return
+		}
+
+		// Atualiza os inimigos
+		for _, e := range w.Enemies {
+			e.update(w)
+		}
+
+		// Verifica colisões dos projéteis de todos os inimigos
+		for _, e := range w.Enemies {
+			for i := range e.Bullets {
+				b := &e.Bullets[i]
+				if !b.Active || b.Owner == nil || !b.Owner.Alive {
+					continue
+				}
+				bulletGridX, bulletGridY := w.Level.CellAt(b.X, b.Y)
+
+				// Verifica se o jogador está tentando refletir o projétil, que
+				// precisa estar chegando pela linha ou coluna dele ou, se vier
+				// de lado, perto dele
+				if in.Reflect {
+					near := bulletGridX == w.PlayerX && math.Abs(float64(bulletGridY-w.PlayerY)) <= 1.4
+					if b.DX != 0 && b.DY == 0 {
+						near = bulletGridY == w.PlayerY && math.Abs(float64(bulletGridX-w.PlayerX)) <= 1.4
+					} else if b.DX != 0 {
+						px, py := w.Level.Center(w.PlayerX, w.PlayerY)
+						near = math.Hypot(b.X-px, b.Y-py) <= 1.4
+					}
+					if near {
+						b.reflect()
+						b.Owner.Behavior.Reflected(w, b.Owner, b)
+						return
+					}
+				}
+
+				// Colisão normal se não foi refletido
+				if b.hits(w, w.PlayerX, w.PlayerY) && !b.Reflected {
+					w.Lives--
+					b.Active = false
+					if w.Lives <= 0 {
+						w.lose("Atingido! Pressione R para tentar novamente")
+						return
+					}
+				}
+			}
+		}
+
+		if in.Flag {
+			w.toggleFlag(in.FlagX, in.FlagY)
+		}
+
+		w.updateRocks(in)
+		w.updateLight(in)
+
+		if !w.Aiming && (in.MoveX != 0 || in.MoveY != 0) {
+			w.tryMove(in.MoveX, in.MoveY)
+		}
+
+		if w.State == Playing {
+			w.updateWalkers()
+		}
+	}
+
+	if in.Restart {
+		if w.restart {
+			w.reset()
+		} else {
+			// Só volta para a entrada, sem reiniciar o nível
+			w.PlayerX = w.Level.Start
+			w.PlayerY = -1
+			w.Stuck = 0
+			w.State = Playing
+			w.ShowTraps = false
+			w.Aiming = false
+			w.Message = ""
+		}
+	}
+	if in.Advance && w.State == Won {
+		w.enterLevel(w.nextLevel())
+	}
+}
+
+// togglePause entra ou sai da pausa. Pausar na memorização esconde o
+// tabuleiro, senão a pausa serviria para memorizar sem pressa.
+func (w *World) togglePause() {
+	if w.State == Paused {
+		w.State = w.resumeState
+		w.Message = w.resumeMsg
+		w.ShowTraps = w.State == Memorizing
+		return
+	}
+	w.resumeState, w.resumeMsg = w.State, w.Message
+	w.State = Paused
+	w.ShowTraps = false
+	w.Message = "Pausado"
+}
+
+// toggleFlag põe ou tira a bandeira da célula (x, y)
+func (w *World) toggleFlag(x, y int) {
+	if !w.Level.Inside(x, y) {
+		return
+	}
+	node := w.Level.Node(x, y)
+	if w.Flags[node] {
+		delete(w.Flags, node)
+	} else {
+		w.Flags[node] = true
+	}
+}
+
+// FlagScore devolve quantas bandeiras estão em armadilhas e quantas foram postas
+func (w *World) FlagScore() (correct, placed int) {
+	for node := range w.Flags {
+		if w.Level.Traps[node] {
+			correct++
+		}
+	}
+	return correct, len(w.Flags)
+}
+
+func (w *World) lose(message string) {
+	w.State = Lost
+	w.restart = true
+	w.FallenTraps = make(map[int]bool)
+	w.Message = message
+}
+
+// updateRocks trata a mira e o lançamento de pedras
+func (w *World) updateRocks(in Input) {
+	// Entra/sai do modo de mira
+	if in.Aim && w.RocksLeft > 0 {
+		w.Aiming = !w.Aiming
+		w.AimX = w.PlayerX
+		w.AimY = w.PlayerY
+	}
+
+	if w.Aiming && w.Level.Hex {
+		// Na grade hexagonal a mira anda de hexágono em hexágono, até 5 passos
+		// do jogador
+		x, y, ok := w.Level.Move(w.AimX, w.AimY, in.MoveX, in.MoveY)
+		if ok && x >= 0 && x < w.Level.Width && y >= 0 && y < w.Level.Height && w.Level.Distance(x, y, w.PlayerX, w.PlayerY) <= 5 {
+			w.AimX, w.AimY = x, y
+		}
+	} else if w.Aiming {
+		// Limita a distância da mira ao jogador
+		dx := w.AimX - w.PlayerX
+		dy := w.AimY - w.PlayerY
+		distance := math.Sqrt(float64(dx*dx + dy*dy))
+		maxDistance := 5.0
+		if distance > maxDistance {
+			angle := math.Atan2(float64(dy), float64(dx))
+			w.AimX = w.PlayerX + int(maxDistance*math.Cos(angle))
+			w.AimY = w.PlayerY + int(maxDistance*math.Sin(angle))
+		}
+		if in.MoveX < 0 && w.AimX > 0 {
+			w.AimX--
+		}
+		if in.MoveX > 0 && w.AimX < w.Level.Width-1 {
+			w.AimX++
+		}
+		if in.MoveY > 0 && w.AimY < w.Level.Height-1 {
+			w.AimY++
+		}
+		if in.MoveY < 0 && w.AimY > 0 {
+			w.AimY--
+		}
+	}
+
+	// Lança a pedra (só em células do tabuleiro)
+	if w.Aiming && in.Throw && w.Level.Inside(w.AimX, w.AimY) {
+		w.RocksLeft--
+		node := w.Level.Node(w.AimX, w.AimY)
+
+		x, y := w.Level.Center(w.PlayerX, w.PlayerY)
+		rock := Rock{
+			X:        x,
+			Y:        y,
+			TargetX:  w.AimX,
+			TargetY:  w.AimY,
+			Active:   true,
+			Revealed: make(map[int]bool),
+		}
+		rock.Revealed[node] = true
+
+		if node == w.Level.Treasure {
+			w.State = Won
+			w.Message = "Você achou o tesouro! Pressione ENTER para continuar"
+		} else if w.Level.Traps[node] {
+			w.FallenTraps[node] = true
+		} else {
+			w.Visited[node] = true
+		}
+
+		w.Rocks = append(w.Rocks, rock)
+		w.Aiming = false
+	}
+
+	// Atualiza a posição das pedras
+	const speed = 0.2
+	for i := range w.Rocks {
+		r := &w.Rocks[i]
+		if !r.Active {
+			continue
+		}
+		targetX, targetY := w.Level.Center(r.TargetX, r.TargetY)
+		dx := targetX - r.X
+		dy := targetY - r.Y
+		length := math.Sqrt(dx*dx + dy*dy)
+		if length <= speed {
+			r.X, r.Y = targetX, targetY
+			r.Active = false
+			// No escuro, a pedra ilumina por um instante onde caiu
+			if w.Level.Dark {
+				w.Glows = append(w.Glows, Glow{X: targetX, Y: targetY, Ticks: glowTicks})
+			}
+			continue
+		}
+		r.X += (dx / length) * speed
+		r.Y += (dy / length) * speed
+	}
+}
+
+func (w *World) tryMove(dx, dy int) {
+	if w.Stuck > 0 {
+		w.Stuck--
+		return
+	}
+	newX, newY, ok := w.Level.Move(w.PlayerX, w.PlayerY, dx, dy)
+	if !ok || w.Level.Blocked(w.PlayerX, w.PlayerY, newX, newY) {
+		return
+	}
+	node := w.Level.Node(newX, newY)
+
+	// Verifica se o movimento é válido (dentro do tabuleiro ou logo abaixo dele)
+	entrance := newY == -1 && newX >= 0 && newX < w.Level.Width
+	if (entrance || w.Level.Inside(newX, newY)) && !w.FallenTraps[node] {
+		w.PlayerX = newX
+		w.PlayerY = newY
+
+		// Só verifica colisões dentro do grid
+		if newY >= 0 {
+			// Caiu numa armadilha: o efeito depende do tipo
+			if w.Level.Traps[node] {
+				w.stepOnTrap(node)
+			} else {
+				w.Visited[node] = true
+			}
+
+			// Achou o tesouro
+			if node == w.Level.Treasure {
+				w.State = Won
+				w.Aiming = false
+				w.Message = "Você ganhou! Pressione ENTER para avançar"
+			}
+		}
+	}
+}
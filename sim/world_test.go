@@ -0,0 +1,280 @@
+package sim
+
+import (
+	"reflect"
+	"testing"
+
+	"example/tesourim/utils"
+)
+
+// testWorld cria uma partida num tabuleiro 6×6 feito à mão, já na fase de
+// jogo. Com y = 0 na linha da entrada, o canto de baixo à esquerda é:
+//
+//	. . T
+//	. S .
+//	. X #
+//
+// X é um buraco (nó 1), S são espinhos (nó 7), # uma célula vazia (nó 2) e
+// T o tesouro (nó 14); o resto é livre.
+func testWorld(lives int) *World {
+	l := NewLevel(Board{
+		Width:      6,
+		Height:     6,
+		Void:       map[int]bool{2: true},
+		Difficulty: 1,
+		Treasure:   14,
+		Traps:      map[int]bool{1: true, 7: true},
+		TrapTypes:  map[int]TrapType{7: Spike},
+	})
+	l.Lives = lives
+	w := NewWorld(1)
+	w.enterLevel(l)
+	w.Step(Input{Throw: true}) // Pula a memorização
+	return w
+}
+
+// play manda um movimento por tick
+func play(w *World, moves ...[2]int) {
+	for _, m := range moves {
+		w.Step(Input{MoveX: m[0], MoveY: m[1]})
+	}
+}
+
+var (
+	up        = [2]int{0, 1}
+	down      = [2]int{0, -1}
+	right     = [2]int{1, 0}
+	upRight   = [2]int{1, 1}
+	downRight = [2]int{1, -1}
+)
+
+// TestStep confere movimento, armadilhas e vitória a partir da entrada
+func TestStep(t *testing.T) {
+	tests := []struct {
+		name   string
+		lives  int
+		moves  [][2]int
+		x, y   int
+		state  State
+		lives2 int
+		fallen []int
+	}{
+		{"entra no tabuleiro", 2, [][2]int{up}, 0, 0, Playing, 2, nil},
+		{"anda até a borda", 2, [][2]int{up, up, up, up, up, up, up, up}, 0, 5, Playing, 2, nil},
+		{"cai no buraco e volta à entrada", 2, [][2]int{up, right}, 0, -1, Playing, 2, []int{1}},
+		{"espinhos tiram uma vida", 2, [][2]int{up, upRight}, 1, 1, Playing, 1, []int{7}},
+		{"espinhos na última vida perdem o nível", 1, [][2]int{up, upRight}, 1, 1, Lost, 0, nil},
+		{"acha o tesouro", 2, [][2]int{up, up, up, right, right}, 2, 2, Won, 2, nil},
+		{"não entra num buraco caído", 2, [][2]int{up, right, up, right}, 0, 0, Playing, 2, []int{1}},
+		{"não entra no vazio", 2, [][2]int{up, up, upRight, downRight, down}, 2, 1, Playing, 2, nil},
+		{"vai pela direita", 2, [][2]int{up, up, upRight, downRight}, 2, 1, Playing, 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testWorld(tt.lives)
+			play(w, tt.moves...)
+			if w.PlayerX != tt.x || w.PlayerY != tt.y {
+				t.Errorf("jogador em (%d, %d), queria (%d, %d)", w.PlayerX, w.PlayerY, tt.x, tt.y)
+			}
+			if w.State != tt.state {
+				t.Errorf("estado %d, queria %d", w.State, tt.state)
+			}
+			if w.Lives != tt.lives2 {
+				t.Errorf("%d vidas, queria %d", w.Lives, tt.lives2)
+			}
+			for _, node := range tt.fallen {
+				if !w.FallenTraps[node] {
+					t.Errorf("armadilha %d não caiu", node)
+				}
+			}
+		})
+	}
+}
+
+// TestMovement confere que o jogador só dá os passos da regra do tabuleiro
+func TestMovement(t *testing.T) {
+	w := testWorld(2)
+	w.Level.Movement = utils.Orthogonal
+	play(w, up, upRight)
+	if w.PlayerX != 0 || w.PlayerY != 0 {
+		t.Errorf("torre andou na diagonal, para (%d, %d)", w.PlayerX, w.PlayerY)
+	}
+	w.Level.Movement = utils.Knight
+	play(w, [2]int{1, 2})
+	if w.PlayerX != 1 || w.PlayerY != 2 {
+		t.Errorf("cavalo em (%d, %d), queria (1, 2)", w.PlayerX, w.PlayerY)
+	}
+}
+
+// TestWalls confere que paredes barram passos, mas não o canto de uma
+// diagonal que tem um caminho em L aberto em volta
+func TestWalls(t *testing.T) {
+	w := testWorld(2)
+	w.Level.Walls = []utils.Wall{utils.NewWall(0, 6)}
+	play(w, up, up)
+	if w.PlayerX != 0 || w.PlayerY != 0 {
+		t.Errorf("atravessou a parede, para (%d, %d)", w.PlayerX, w.PlayerY)
+	}
+	play(w, upRight)
+	if w.PlayerX != 1 || w.PlayerY != 1 {
+		t.Errorf("diagonal pelo canto aberto: jogador em (%d, %d), queria (1, 1)", w.PlayerX, w.PlayerY)
+	}
+}
+
+// TestHexRocks mira e lança pedras numa grade hexagonal: uma numa
+// armadilha e outra numa célula livre
+func TestHexRocks(t *testing.T) {
+	l := NewLevel(Board{
+		Width:      6,
+		Height:     6,
+		Hex:        true,
+		Movement:   utils.King,
+		Difficulty: 1,
+		Treasure:   30,
+		Traps:      map[int]bool{8: true},
+	})
+	w := NewWorld(1)
+	w.enterLevel(l)
+	w.Step(Input{Throw: true}) // Pula a memorização
+	w.PlayerX, w.PlayerY = 1, 1
+	rocks := w.RocksLeft
+
+	w.Step(Input{Aim: true})
+	w.Step(Input{MoveX: 1})
+	w.Step(Input{Throw: true})
+	if w.AimX != 2 || w.AimY != 1 || w.Aiming {
+		t.Errorf("mira em (%d, %d), mirando %v; queria (2, 1) e a mira fechada", w.AimX, w.AimY, w.Aiming)
+	}
+	if w.RocksLeft != rocks-1 || len(w.Rocks) != 1 || !w.FallenTraps[8] {
+		t.Errorf("%d pedras, %d lançadas, armadilha caída %v", w.RocksLeft, len(w.Rocks), w.FallenTraps[8])
+	}
+
+	w.Step(Input{Aim: true})
+	w.Step(Input{MoveX: -1, MoveY: 1})
+	w.Step(Input{Throw: true})
+	node := w.Level.Node(w.AimX, w.AimY)
+	if w.RocksLeft != rocks-2 || len(w.Rocks) != 2 || !w.Visited[node] {
+		t.Errorf("%d pedras, %d lançadas, célula (%d, %d) visitada %v", w.RocksLeft, len(w.Rocks), w.AimX, w.AimY, w.Visited[node])
+	}
+	if w.PlayerX != 1 || w.PlayerY != 1 {
+		t.Errorf("jogador andou enquanto mirava, para (%d, %d)", w.PlayerX, w.PlayerY)
+	}
+}
+
+// TestFlags põe e tira bandeiras e confere a contagem de acertos
+func TestFlags(t *testing.T) {
+	w := testWorld(2)
+	for _, cell := range [][2]int{{1, 0}, {0, 1}, {4, 4}, {2, 0}, {-1, 0}, {0, 1}} {
+		w.Step(Input{Flag: true, FlagX: cell[0], FlagY: cell[1]})
+	}
+	// O vazio e a célula fora do tabuleiro não recebem bandeira, e a segunda
+	// bandeira em (0, 1) tira a primeira
+	if correct, placed := w.FlagScore(); correct != 1 || placed != 2 {
+		t.Errorf("%d de %d bandeiras certas, queria 1 de 2", correct, placed)
+	}
+}
+
+// TestMemorizing confere que o jogador não anda na memorização
+func TestMemorizing(t *testing.T) {
+	w := testWorld(2)
+	w.enterLevel(w.Level) // Volta à memorização
+	play(w, up)
+	if w.State != Memorizing || w.PlayerY != -1 {
+		t.Errorf("na memorização: estado %d, jogador em y=%d", w.State, w.PlayerY)
+	}
+	for i := 0; i < w.Level.MemorizeTime; i++ {
+		w.Step(Input{})
+	}
+	if w.State != Playing || w.ShowTraps {
+		t.Errorf("depois da memorização: estado %d, armadilhas visíveis %v", w.State, w.ShowTraps)
+	}
+}
+
+// TestTimeout confere que o tempo esgotado perde o nível
+func TestTimeout(t *testing.T) {
+	w := testWorld(2)
+	w.GameTimer = 3
+	for i := 0; i < 3; i++ {
+		w.Step(Input{})
+	}
+	if w.State != Lost {
+		t.Errorf("estado %d depois do tempo, queria Lost", w.State)
+	}
+}
+
+// TestRestart confere os dois usos do R: no meio do nível, volta à entrada
+// sem desfazer nada; depois da derrota, recomeça o nível do zero
+func TestRestart(t *testing.T) {
+	w := testWorld(2)
+	play(w, up, right, up, up)
+	w.Step(Input{Restart: true})
+	if w.PlayerX != 0 || w.PlayerY != -1 || w.State != Playing || !w.FallenTraps[1] {
+		t.Errorf("R no meio do nível: jogador em (%d, %d), estado %d, buraco caído %v", w.PlayerX, w.PlayerY, w.State, w.FallenTraps[1])
+	}
+
+	w = testWorld(1)
+	play(w, up, upRight)
+	if w.State != Lost {
+		t.Fatalf("estado %d, queria Lost", w.State)
+	}
+	play(w, up) // Perdido, nada anda
+	if w.PlayerX != 1 || w.PlayerY != 1 {
+		t.Errorf("jogador andou depois da derrota, para (%d, %d)", w.PlayerX, w.PlayerY)
+	}
+	round := w.Round
+	w.Step(Input{Restart: true})
+	if w.State != Memorizing || w.Lives != 1 || w.PlayerY != -1 || len(w.FallenTraps) != 0 || w.Round != round {
+		t.Errorf("R depois da derrota: estado %d, %d vidas, jogador em y=%d, %d armadilhas caídas, rodada %d",
+			w.State, w.Lives, w.PlayerY, len(w.FallenTraps), w.Round)
+	}
+}
+
+// TestAdvance confere que ENTER só passa de nível depois da vitória
+func TestAdvance(t *testing.T) {
+	w := testWorld(2)
+	round := w.Round
+	w.Step(Input{Advance: true})
+	if w.Round != round {
+		t.Fatalf("ENTER sem vitória passou para a rodada %d", w.Round)
+	}
+	play(w, up, up, up, right, right)
+	w.Step(Input{Advance: true})
+	if w.Round != round+1 || w.Level.Difficulty != 2 || w.State != Memorizing || w.Level.Size() != 6 {
+		t.Errorf("depois da vitória: rodada %d, dificuldade %d, estado %d, grid %d", w.Round, w.Level.Difficulty, w.State, w.Level.Size())
+	}
+}
+
+// TestPause confere que a pausa congela o tempo e o jogador, e que pausar
+// na memorização esconde o tabuleiro
+func TestPause(t *testing.T) {
+	w := testWorld(2)
+	w.Step(Input{Pause: true})
+	timer := w.GameTimer
+	play(w, up, up, up)
+	if w.State != Paused || w.GameTimer != timer || w.PlayerY != -1 {
+		t.Errorf("pausado: estado %d, timer %d (era %d), jogador em y=%d", w.State, w.GameTimer, timer, w.PlayerY)
+	}
+	w.Step(Input{Pause: true})
+	play(w, up)
+	if w.State != Playing || w.PlayerY != 0 {
+		t.Errorf("depois da pausa: estado %d, jogador em y=%d", w.State, w.PlayerY)
+	}
+
+	w.enterLevel(w.Level)
+	w.Step(Input{Pause: true})
+	if w.ShowTraps {
+		t.Error("pausa na memorização mostra as armadilhas")
+	}
+	w.Step(Input{Pause: true})
+	if w.State != Memorizing || !w.ShowTraps {
+		t.Errorf("depois da pausa: estado %d, armadilhas visíveis %v", w.State, w.ShowTraps)
+	}
+}
+
+// TestSeed confere que a mesma seed sorteia o mesmo tabuleiro
+func TestSeed(t *testing.T) {
+	a, b := NewWorld(42), NewWorld(42)
+	if !reflect.DeepEqual(a.Board(), b.Board()) {
+		t.Errorf("seed 42 sorteou dois tabuleiros: %+v e %+v", a.Board(), b.Board())
+	}
+}
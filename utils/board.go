@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+)
+
+// ErrNoEntrance é devolvido por GenerateBoard para formatos sem nenhuma
+// célula de entrada, onde não há por onde começar o caminho seguro
+var ErrNoEntrance = errors.New("utils: formato sem entrada")
+
+// PathOptions controla o caminho seguro escavado por GenerateBoard
+type PathOptions struct {
+	Length    int  // Comprimento desejado do caminho, em células
+	Turns     int  // Quantas vezes o caminho muda de direção
+	Diagonals bool // Se o caminho pode usar passos diagonais
+}
+
+// BoardScore resume o quão difícil é um tabuleiro
+type BoardScore struct {
+	PathLength  int     // Células no menor caminho seguro até o tesouro
+	Routes      int     // Quantos menores caminhos distintos existem
+	Chokepoints int     // Células seguras que, se virassem armadilha, isolariam o tesouro
+	Score       float64 // Nota combinada, normalizada pelo maior caminho possível no formato
+}
+
+// GenerateBoard gera um tabuleiro sempre resolvível no formato shape: primeiro
+// escava um caminho seguro a partir da primeira linha, coloca o tesouro no fim
+// dele e só depois espalha as armadilhas nas outras células. Devolve o
+// tesouro, as armadilhas e o caminho escavado, ou ErrNoEntrance.
+func GenerateBoard(rng *rand.Rand, shape Shape, dificulty int, opts PathOptions) (int, map[int]bool, []int, error) {
+	entrances := shape.Entrances()
+	if len(entrances) == 0 {
+		return 0, nil, nil, ErrNoEntrance
+	}
+	path := carvePath(rng, shape, entrances, opts)
+	treasure := path[len(path)-1]
+
+	safe := make(map[int]bool, len(path))
+	for _, node := range path {
+		safe[node] = true
+	}
+
+	traps := make(map[int]bool)
+	maxTraps := trapCount(len(shape.Nodes()), dificulty)
+	for _, node := range rng.Perm(shape.Width * shape.Height) {
+		if len(traps) >= maxTraps {
+			break
+		}
+		if !safe[node] && !shape.Void[node] {
+			traps[node] = true
+		}
+	}
+	return treasure, traps, path, nil
+}
+
+// trapCount é a mesma densidade de armadilhas usada por GenerateTraps, para um
+// tabuleiro com cells células
+func trapCount(cells int, dificulty int) int {
+	maxTraps := int(float64(cells) * 0.75)
+	switch dificulty {
+	case 1:
+		maxTraps = int(float64(maxTraps) * 0.6)
+	case 2:
+		maxTraps = int(float64(maxTraps) * 0.8)
+	}
+	return maxTraps
+}
+
+// carvePath anda pelo grid sem repetir células, a partir de uma das
+// entrances, em Turns+1 segmentos retos de tamanho parecido. Quando um segmento bate na borda ou no próprio caminho,
+// o próximo segmento começa mais cedo.
+func carvePath(rng *rand.Rand, shape Shape, entrances []int, opts PathOptions) []int {
+	// Em grades hexagonais os passos retos em offset são sempre vizinhos, mas
+	// as diagonais dependem da paridade da linha, então ficam de fora. Fora do
+	// rei, o caminho usa os passos da regra de movimento do tabuleiro.
+	directions := [][2]int{{0, 1}, {1, 0}, {-1, 0}, {0, -1}}
+	switch {
+	case shape.Hex:
+	case shape.Movement != King:
+		directions = shape.Movement.Steps()
+	case opts.Diagonals:
+		directions = append(directions, [2]int{1, 1}, [2]int{-1, 1}, [2]int{1, -1}, [2]int{-1, -1})
+	}
+
+	start := entrances[rng.Intn(len(entrances))]
+	row, col := start/shape.Width, start%shape.Width
+	path := []int{start}
+	visited := map[int]bool{start: true}
+
+	// Um passo diagonal que passa pelo canto entre duas células vazias não
+	// existe no grafo, então também não entra no caminho
+	free := func(r, c int) bool {
+		return shape.Inside(c, r) && !visited[r*shape.Width+c] && !shape.blocked(nil, row*shape.Width+col, r*shape.Width+c)
+	}
+
+	length := opts.Length
+	if length < 1 {
+		length = 1
+	}
+	segments := opts.Turns + 1
+	var last [2]int
+	for seg := 0; seg < segments && len(path) < length; seg++ {
+		segLen := (length - len(path) + segments - seg - 1) / (segments - seg)
+
+		// Escolhe uma direção nova, com preferência por subir no grid
+		options := make([][2]int, 0, len(directions))
+		for _, dir := range directions {
+			if dir == last || !free(row+dir[1], col+dir[0]) {
+				continue
+			}
+			options = append(options, dir)
+			if dir[1] > 0 {
+				options = append(options, dir)
+			}
+		}
+		if len(options) == 0 {
+			break
+		}
+		dir := options[rng.Intn(len(options))]
+		last = dir
+
+		for step := 0; step < segLen && free(row+dir[1], col+dir[0]); step++ {
+			row, col = row+dir[1], col+dir[0]
+			node := row*shape.Width + col
+			path = append(path, node)
+			visited[node] = true
+		}
+	}
+	return path
+}
+
+// ScoreBoard mede a dificuldade de um tabuleiro a partir do menor caminho
+// seguro entre as células de entrada (starts) e o tesouro. Tabuleiros sem
+// caminho recebem nota zero.
+func ScoreBoard(graph map[int][]int, traps map[int]bool, starts []int, target int) BoardScore {
+	dist, routes := bfsRoutes(graph, traps, starts)
+	d, ok := dist[target]
+	if !ok {
+		return BoardScore{}
+	}
+
+	// A nota é relativa ao maior menor caminho que o formato permite: a altura
+	// num quadrado com o movimento do rei, mas não em retângulos ou com
+	// outras regras de movimento
+	open, _ := bfsRoutes(graph, nil, starts)
+	longest := 0
+	for _, d := range open {
+		longest = max(longest, d)
+	}
+	L := float64(longest + 1)
+	score := BoardScore{
+		PathLength:  d + 1,
+		Routes:      routes[target],
+		Chokepoints: len(ArticulationCells(graph, traps, starts, target)),
+	}
+	score.Score = float64(score.PathLength+2*score.Chokepoints) / L / (1 + math.Log2(float64(score.Routes))/4)
+	return score
+}
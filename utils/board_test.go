@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+// TestGenerateBoard confere que o caminho escavado é seguro, anda pelo grid
+// sem repetir células e termina no tesouro
+func TestGenerateBoard(t *testing.T) {
+	for _, s := range testShapes {
+		graph := GenerateShapeGraph(s)
+		maxTraps := trapCount(len(s.Nodes()), 2)
+		for _, opts := range []PathOptions{
+			{Length: 7, Turns: 1, Diagonals: true},
+			{Length: 14, Turns: 5},
+		} {
+			for seed := int64(1); seed <= 20; seed++ {
+				treasure, traps, path, err := GenerateBoard(NewRand(seed), s, 2, opts)
+				if err != nil {
+					t.Fatalf("%+v, %+v, seed %d: %v", s, opts, seed, err)
+				}
+				if len(path) == 0 || !slices.Contains(s.Entrances(), path[0]) || path[len(path)-1] != treasure {
+					t.Fatalf("%+v, %+v, seed %d: caminho %v não vai da entrada até o tesouro %d", s, opts, seed, path, treasure)
+				}
+				for i, node := range path {
+					if traps[node] {
+						t.Errorf("%+v, %+v, seed %d: armadilha %d no caminho", s, opts, seed, node)
+					}
+					if slices.Contains(path[:i], node) {
+						t.Errorf("%+v, %+v, seed %d: caminho %v passa duas vezes por %d", s, opts, seed, path, node)
+					}
+					if i > 0 && !slices.Contains(graph[path[i-1]], node) {
+						t.Errorf("%+v, %+v, seed %d: caminho %v pula de %d para %d", s, opts, seed, path, path[i-1], node)
+					}
+				}
+				for node := range traps {
+					if s.Void[node] {
+						t.Errorf("%+v, %+v, seed %d: armadilha no vazio %d", s, opts, seed, node)
+					}
+				}
+				if len(traps) > maxTraps {
+					t.Errorf("%+v, %+v, seed %d: %d armadilhas, mais que as %d da dificuldade", s, opts, seed, len(traps), maxTraps)
+				}
+			}
+		}
+	}
+}
+
+// TestGenerateBoardDiagonalPinch confere que o caminho não corta o canto
+// entre duas células vazias. No formato abaixo, a entrada 0 só sai pela
+// diagonal até 4, que o grafo não tem:
+//
+//	. . .
+//	# . .
+//	. # .
+func TestGenerateBoardDiagonalPinch(t *testing.T) {
+	s := Shape{Width: 3, Height: 3, Void: map[int]bool{1: true, 3: true}}
+	graph := GenerateShapeGraph(s)
+	for seed := int64(1); seed <= 20; seed++ {
+		_, _, path, err := GenerateBoard(NewRand(seed), s, 1, PathOptions{Length: 5, Turns: 1, Diagonals: true})
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		for i := 1; i < len(path); i++ {
+			if !slices.Contains(graph[path[i-1]], path[i]) {
+				t.Errorf("seed %d: caminho %v corta o canto de %d para %d", seed, path, path[i-1], path[i])
+			}
+		}
+	}
+}
+
+// TestGenerateBoardNoEntrance pede um tabuleiro num formato com a linha de
+// baixo toda vazia, sem entrada
+func TestGenerateBoardNoEntrance(t *testing.T) {
+	s := Shape{Width: 3, Height: 3, Void: map[int]bool{0: true, 1: true, 2: true}}
+	if _, _, _, err := GenerateBoard(NewRand(1), s, 1, PathOptions{Length: 3}); !errors.Is(err, ErrNoEntrance) {
+		t.Errorf("erro %v, queria ErrNoEntrance", err)
+	}
+}
+
+func TestScoreBoard(t *testing.T) {
+	graph := GenerateGraph(3)
+	starts := SquareShape(3).Entrances()
+	tests := []struct {
+		name  string
+		traps map[int]bool
+		want  BoardScore
+	}{
+		// Três linhas, sete caminhos mínimos de 0, 1 ou 2 até 7, sem gargalos
+		{"livre", nil, BoardScore{PathLength: 3, Routes: 7}},
+		// Só 1 → 4 → 7: o meio é gargalo
+		{"corredor", map[int]bool{0: true, 2: true, 3: true, 5: true}, BoardScore{PathLength: 3, Routes: 1, Chokepoints: 2}},
+		{"sem caminho", map[int]bool{3: true, 4: true, 5: true}, BoardScore{}},
+	}
+	for _, tt := range tests {
+		got := ScoreBoard(graph, tt.traps, starts, 7)
+		want := tt.want
+		if want.Routes > 0 {
+			want.Score = float64(want.PathLength+2*want.Chokepoints) / 3 / (1 + math.Log2(float64(want.Routes))/4)
+		}
+		if got != want {
+			t.Errorf("%s: %+v, queria %+v", tt.name, got, want)
+		}
+	}
+
+	// A nota não depende da largura: o mesmo corredor num 9×3 vale o mesmo
+	wide := Shape{Width: 9, Height: 3}
+	corridor := map[int]bool{0: true, 2: true, 9: true, 11: true}
+	for node := 3; node < 9; node++ {
+		corridor[node], corridor[9+node] = true, true
+	}
+	want := ScoreBoard(graph, map[int]bool{0: true, 2: true, 3: true, 5: true}, starts, 7)
+	if got := ScoreBoard(GenerateShapeGraph(wide), corridor, wide.Entrances(), 19); got != want {
+		t.Errorf("corredor no 9×3: %+v, queria %+v como no 3×3", got, want)
+	}
+
+	// Menos caminhos deixam o tabuleiro mais difícil
+	open := ScoreBoard(graph, nil, starts, 7).Score
+	narrow := ScoreBoard(graph, map[int]bool{0: true, 2: true, 3: true, 5: true}, starts, 7).Score
+	if narrow <= open {
+		t.Errorf("corredor com nota %.3f, não maior que a do tabuleiro livre (%.3f)", narrow, open)
+	}
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// validPath confere que path é um caminho seguro no grafo, de uma das starts
+// até target
+func validPath(t *testing.T, graph map[int][]int, traps map[int]bool, starts []int, target int, path []int) {
+	t.Helper()
+	if len(path) == 0 || !slices.Contains(starts, path[0]) || path[len(path)-1] != target {
+		t.Fatalf("caminho %v não vai de %v até %d", path, starts, target)
+	}
+	for i, node := range path {
+		if traps[node] {
+			t.Fatalf("caminho %v passa pela armadilha %d", path, node)
+		}
+		if i > 0 && !slices.Contains(graph[path[i-1]], node) {
+			t.Fatalf("caminho %v pula de %d para %d", path, path[i-1], node)
+		}
+	}
+}
+
+// randomTraps sorteia armadilhas em cerca de 30% das células, nunca em keep
+func randomTraps(seed int64, s Shape, keep ...int) map[int]bool {
+	rng := NewRand(seed)
+	traps := make(map[int]bool)
+	for _, node := range s.Nodes() {
+		if rng.Intn(10) < 3 && !slices.Contains(keep, node) {
+			traps[node] = true
+		}
+	}
+	return traps
+}
+
+// testShapes são os formatos dos testes com tabuleiros sorteados
+var testShapes = []Shape{
+	SquareShape(7),
+	{Width: 7, Height: 7, Hex: true},
+	{Width: 7, Height: 7, Movement: Orthogonal},
+	{Width: 7, Height: 7, Movement: Knight},
+	LShape(7, 7),
+	RingShape(7, 7),
+}
+
+func TestShortestPath(t *testing.T) {
+	graph := GenerateGraph(3) // Rei num 3×3: nós 0 a 8, linha 0 na entrada
+	tests := []struct {
+		name   string
+		traps  map[int]bool
+		starts []int
+		target int
+		length int // Nós no caminho (0 = sem caminho)
+	}{
+		{"diagonal livre", nil, []int{0}, 8, 3},
+		{"desvia do centro", map[int]bool{4: true}, []int{0}, 8, 4},
+		{"linha fechada", map[int]bool{3: true, 4: true, 5: true}, []int{0}, 8, 0},
+		{"várias entradas", nil, []int{0, 1, 2}, 8, 3},
+		{"entrada é o alvo", nil, []int{0, 1, 2}, 1, 1},
+		{"entrada com armadilha", map[int]bool{0: true}, []int{0}, 8, 0},
+	}
+	for _, tt := range tests {
+		path := ShortestPath(graph, tt.traps, tt.starts, tt.target)
+		if len(path) != tt.length {
+			t.Errorf("%s: caminho %v, queria %d nós", tt.name, path, tt.length)
+			continue
+		}
+		if tt.length > 0 {
+			validPath(t, graph, tt.traps, tt.starts, tt.target, path)
+		}
+	}
+}
+
+func TestAStar(t *testing.T) {
+	for _, s := range testShapes {
+		graph := GenerateShapeGraph(s)
+		// Heurística zero: nunca superestima, e o A* vira Dijkstra
+		zero := func(int) int { return 0 }
+		for seed := int64(1); seed <= 20; seed++ {
+			start, target := s.Nodes()[0], s.Nodes()[len(s.Nodes())-1]
+			traps := randomTraps(seed, s, start, target)
+			got := AStar(graph, traps, start, target, zero)
+			want := ShortestPath(graph, traps, []int{start}, target)
+			if len(got) != len(want) {
+				t.Errorf("%+v, seed %d: A* achou %v, BFS achou %v", s, seed, got, want)
+				continue
+			}
+			if got != nil {
+				validPath(t, graph, traps, []int{start}, target, got)
+			}
+		}
+	}
+
+	// Armadilha na saída ou na chegada: não há caminho
+	graph := GenerateGraph(3)
+	h := func(int) int { return 0 }
+	if path := AStar(graph, map[int]bool{0: true}, 0, 8, h); path != nil {
+		t.Errorf("saída com armadilha: caminho %v", path)
+	}
+	if path := AStar(graph, map[int]bool{8: true}, 0, 8, h); path != nil {
+		t.Errorf("chegada com armadilha: caminho %v", path)
+	}
+}
+
+// TestArticulationCells compara com a definição: uma célula segura é
+// essencial se pôr uma armadilha nela desconecta o tesouro das entradas
+func TestArticulationCells(t *testing.T) {
+	found := 0
+	for _, s := range testShapes {
+		graph := GenerateShapeGraph(s)
+		starts := s.Entrances()
+		for seed := int64(1); seed <= 20; seed++ {
+			target := s.Nodes()[len(s.Nodes())-1]
+			traps := randomTraps(seed, s, target)
+
+			var want []int
+			if ShortestPath(graph, traps, starts, target) != nil {
+				for _, node := range s.Nodes() {
+					if traps[node] || node == target {
+						continue
+					}
+					traps[node] = true
+					if ShortestPath(graph, traps, starts, target) == nil {
+						want = append(want, node)
+					}
+					delete(traps, node)
+				}
+			}
+			got := ArticulationCells(graph, traps, starts, target)
+			slices.Sort(got)
+			found += len(want)
+			if len(got) != len(want) || (len(got) > 0 && !reflect.DeepEqual(got, want)) {
+				t.Errorf("%+v, seed %d: %v, queria %v", s, seed, got, want)
+			}
+		}
+	}
+	if found == 0 {
+		t.Error("nenhum tabuleiro sorteado tem gargalos; o teste não testa nada")
+	}
+}
@@ -0,0 +1,81 @@
+package utils
+
+// Grades hexagonais usam o layout "odd-r": hexágonos com a ponta para cima,
+// linhas empilhadas e as linhas ímpares deslocadas meia célula para a direita.
+// As células continuam indexadas como row*Width + col (coordenadas de offset);
+// as contas de vizinhança e distância são feitas em coordenadas axiais (q, r),
+// com r crescendo para cima como as linhas.
+
+// HexDirections são os 6 vizinhos em coordenadas axiais
+var HexDirections = [6][2]int{
+	{1, 0}, {-1, 0}, // Direita, esquerda
+	{0, 1}, {-1, 1}, // Cima-direita, cima-esquerda
+	{0, -1}, {1, -1}, // Baixo-esquerda, baixo-direita
+}
+
+// OffsetToAxial converte a célula (col, row) em coordenadas axiais
+func OffsetToAxial(col, row int) (int, int) {
+	return col - (row-(row&1))/2, row
+}
+
+// AxialToOffset é o inverso de OffsetToAxial
+func AxialToOffset(q, r int) (int, int) {
+	return q + (r-(r&1))/2, r
+}
+
+// HexDistance é o número de passos entre duas células de uma grade hexagonal
+func HexDistance(col1, row1, col2, row2 int) int {
+	q1, r1 := OffsetToAxial(col1, row1)
+	q2, r2 := OffsetToAxial(col2, row2)
+	dq, dr := q1-q2, r1-r2
+	return (abs(dq) + abs(dr) + abs(dq+dr)) / 2
+}
+
+// HexStep anda uma célula a partir de (col, row) na direção de tela (dx, dy),
+// com dy positivo para cima. Direita/esquerda são (±1, 0) e as quatro
+// diagonais são (±1, ±1); (0, ±1) não existe numa grade hexagonal.
+func HexStep(col, row, dx, dy int) (int, int, bool) {
+	var dir [2]int
+	switch {
+	case dy == 0 && dx != 0:
+		dir = [2]int{dx, 0}
+	case dy > 0 && dx > 0:
+		dir = HexDirections[2]
+	case dy > 0 && dx < 0:
+		dir = HexDirections[3]
+	case dy < 0 && dx < 0:
+		dir = HexDirections[4]
+	case dy < 0 && dx > 0:
+		dir = HexDirections[5]
+	default:
+		return col, row, false
+	}
+	q, r := OffsetToAxial(col, row)
+	col, row = AxialToOffset(q+dir[0], r+dir[1])
+	return col, row, true
+}
+
+// GenerateHexGraph creates the graph of a hexagonal board, with up to 6
+// connections per node. Void cells and walled edges are left out.
+func GenerateHexGraph(s Shape) map[int][]int {
+	graph := make(map[int][]int)
+	walls := wallSet(s.Walls)
+	for _, node := range s.Nodes() {
+		q, r := OffsetToAxial(node%s.Width, node/s.Width)
+		graph[node] = []int{}
+		for _, dir := range HexDirections {
+			col, row := AxialToOffset(q+dir[0], r+dir[1])
+			if neighbor := row*s.Width + col; s.Inside(col, row) && !s.blocked(walls, node, neighbor) {
+				graph[node] = append(graph[node], neighbor)
+			}
+		}
+	}
+	return graph
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
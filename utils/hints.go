@@ -0,0 +1,171 @@
+package utils
+
+import "sort"
+
+// AdjacentTraps conta as armadilhas entre os vizinhos de node no grafo, o
+// número mostrado nas células conhecidas no modo com dicas
+func AdjacentTraps(graph map[int][]int, traps map[int]bool, node int) int {
+	count := 0
+	for _, neighbor := range graph[node] {
+		if traps[neighbor] {
+			count++
+		}
+	}
+	return count
+}
+
+// hintSolver deduz, como um jogador de campo minado, quais células são
+// seguras a partir dos números das células já conhecidas
+type hintSolver struct {
+	graph map[int][]int
+	traps map[int]bool
+	safe  map[int]bool // Células seguras conhecidas, com o número à mostra
+	mined map[int]bool // Armadilhas deduzidas
+}
+
+// unknown devolve os vizinhos de node que ainda não foram deduzidos e quantas
+// armadilhas faltam achar entre eles
+func (s *hintSolver) unknown(node int) ([]int, int) {
+	var cells []int
+	left := AdjacentTraps(s.graph, s.traps, node)
+	for _, neighbor := range s.graph[node] {
+		switch {
+		case s.mined[neighbor]:
+			left--
+		case !s.safe[neighbor]:
+			cells = append(cells, neighbor)
+		}
+	}
+	return cells, left
+}
+
+// mark registra as células de cells como seguras ou como armadilhas
+func (s *hintSolver) mark(cells []int, trap bool) {
+	for _, node := range cells {
+		if trap {
+			s.mined[node] = true
+		} else {
+			s.safe[node] = true
+		}
+	}
+}
+
+// solve aplica as regras do campo minado até não deduzir mais nada: um
+// número já satisfeito libera os vizinhos desconhecidos, um número que só
+// cabe neles marca todos como armadilha e, quando os desconhecidos de um
+// número estão contidos nos de outro, a diferença entre os dois é decidida
+// do mesmo jeito.
+func (s *hintSolver) solve() {
+	for progress := true; progress; {
+		progress = false
+		var clues []int
+		for node := range s.safe {
+			clues = append(clues, node)
+		}
+		sort.Ints(clues)
+
+		type clue struct {
+			cells map[int]bool
+			left  int
+		}
+		var active []clue
+		for _, node := range clues {
+			cells, left := s.unknown(node)
+			switch {
+			case len(cells) == 0:
+			case left == 0 || left == len(cells):
+				s.mark(cells, left > 0)
+				progress = true
+			default:
+				set := make(map[int]bool, len(cells))
+				for _, c := range cells {
+					set[c] = true
+				}
+				active = append(active, clue{set, left})
+			}
+		}
+		if progress {
+			continue
+		}
+
+		for _, a := range active {
+			for _, b := range active {
+				if len(a.cells) >= len(b.cells) || !subset(a.cells, b.cells) {
+					continue
+				}
+				var diff []int
+				for c := range b.cells {
+					if !a.cells[c] {
+						diff = append(diff, c)
+					}
+				}
+				if left := b.left - a.left; left == 0 || left == len(diff) {
+					s.mark(diff, left > 0)
+					progress = true
+				}
+			}
+		}
+	}
+}
+
+// subset diz se todo elemento de a está em b
+func subset(a, b map[int]bool) bool {
+	for c := range a {
+		if !b[c] {
+			return false
+		}
+	}
+	return true
+}
+
+// HintSolvable diz se um jogador que só conhece as entradas seguras consegue
+// chegar ao tesouro deduzindo, pelos números, cada célula em que pisa
+func HintSolvable(graph map[int][]int, traps map[int]bool, starts []int, target int) bool {
+	s := newHintSolver(graph, traps, starts)
+	s.solve()
+	return s.safe[target]
+}
+
+func newHintSolver(graph map[int][]int, traps map[int]bool, starts []int) *hintSolver {
+	s := &hintSolver{graph: graph, traps: traps, safe: make(map[int]bool), mined: make(map[int]bool)}
+	for _, start := range starts {
+		if !traps[start] {
+			s.safe[start] = true
+		}
+	}
+	return s
+}
+
+// MakeHintSolvable tira armadilhas de traps até o tabuleiro ficar
+// HintSolvable: sempre que a dedução empaca, a menor armadilha ainda não
+// deduzida na fronteira do que já se sabe vira célula segura, e a dedução
+// recomeça. Sem armadilhas todo número é zero, então o laço sempre termina.
+// Devolve um mapa novo.
+func MakeHintSolvable(graph map[int][]int, traps map[int]bool, starts []int, target int) map[int]bool {
+	result := make(map[int]bool, len(traps))
+	for node := range traps {
+		if traps[node] {
+			result[node] = true
+		}
+	}
+
+	for {
+		s := newHintSolver(graph, result, starts)
+		s.solve()
+		if s.safe[target] {
+			return result
+		}
+		frontier := -1
+		for node := range s.safe {
+			for _, neighbor := range graph[node] {
+				if result[neighbor] && !s.mined[neighbor] && (frontier < 0 || neighbor < frontier) {
+					frontier = neighbor
+				}
+			}
+		}
+		if frontier < 0 {
+			return result // O tesouro não é alcançável de jeito nenhum
+		}
+		delete(result, frontier)
+	}
+}
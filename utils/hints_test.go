@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestAdjacentTraps confere as dicas num 3×3 com armadilhas nos nós 0, 2 e 7:
+//
+//	6 X 8
+//	3 4 5
+//	X 1 X
+func TestAdjacentTraps(t *testing.T) {
+	graph := GenerateGraph(3)
+	traps := map[int]bool{0: true, 2: true, 7: true}
+	for node, want := range map[int]int{1: 2, 3: 2, 4: 3, 5: 2, 6: 1, 8: 1} {
+		if got := AdjacentTraps(graph, traps, node); got != want {
+			t.Errorf("nó %d: %d armadilhas em volta, queria %d", node, got, want)
+		}
+	}
+}
+
+// TestHintSolvable confere a dedução em tabuleiros 3×3 pequenos, entrando
+// pela linha de baixo
+func TestHintSolvable(t *testing.T) {
+	graph := GenerateGraph(3)
+	starts := []int{0, 1, 2}
+	tests := []struct {
+		name  string
+		traps map[int]bool
+		want  bool
+	}{
+		{"sem armadilhas", nil, true},
+		// O 2 não vê armadilhas e libera o 4 e o 5; daí o 0 acha o 3
+		{"uma armadilha", map[int]bool{3: true}, true},
+		// O 1 vê quatro armadilhas entre cinco vizinhos, e nada separa quais
+		{"chute", map[int]bool{0: true, 2: true, 3: true, 5: true}, false},
+	}
+	for _, tt := range tests {
+		if got := HintSolvable(graph, tt.traps, starts, 7); got != tt.want {
+			t.Errorf("%s: %v, queria %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMakeHintSolvable(t *testing.T) {
+	for _, s := range testShapes {
+		graph := GenerateShapeGraph(s)
+		starts := s.Entrances()
+		for seed := int64(1); seed <= 20; seed++ {
+			target := s.Nodes()[len(s.Nodes())-1]
+			traps := randomTraps(seed, s, target)
+			before := len(traps)
+			got := MakeHintSolvable(graph, traps, starts, target)
+
+			if len(traps) != before {
+				t.Fatalf("%+v, seed %d: MakeHintSolvable mexeu nas armadilhas de entrada", s, seed)
+			}
+			for node := range got {
+				if !traps[node] {
+					t.Errorf("%+v, seed %d: armadilha nova em %d", s, seed, node)
+				}
+			}
+			if ShortestPath(graph, traps, starts, target) != nil && !HintSolvable(graph, got, starts, target) {
+				t.Errorf("%+v, seed %d: tabuleiro ainda não se resolve pelas dicas", s, seed)
+			}
+			// Um tabuleiro que já se resolve não perde nada
+			if again := MakeHintSolvable(graph, got, starts, target); !reflect.DeepEqual(again, got) {
+				t.Errorf("%+v, seed %d: segunda passada tirou %d armadilhas", s, seed, len(got)-len(again))
+			}
+		}
+	}
+}
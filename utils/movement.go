@@ -0,0 +1,53 @@
+package utils
+
+// Movement é a regra de movimento de um tabuleiro quadrado: quais passos
+// (dx, dy) o jogador pode dar. O grafo do tabuleiro e a validação dos
+// movimentos usam a mesma regra, então "resolvível" significa resolvível com
+// os movimentos que o jogador realmente tem.
+type Movement int
+
+const (
+	King       Movement = iota // Um passo em qualquer das 8 direções (padrão)
+	Orthogonal                 // Um passo na horizontal ou na vertical
+	Diagonal                   // Um passo na diagonal
+	Knight                     // Saltos em L, como o cavalo do xadrez
+)
+
+// Movements é o número de regras de movimento
+const Movements = 4
+
+var (
+	orthogonalSteps = [][2]int{{0, 1}, {1, 0}, {-1, 0}, {0, -1}}
+	diagonalSteps   = [][2]int{{1, 1}, {-1, 1}, {1, -1}, {-1, -1}}
+	knightSteps     = [][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+	kingSteps       = [][2]int{{-1, -1}, {0, -1}, {1, -1}, {-1, 0}, {1, 0}, {-1, 1}, {0, 1}, {1, 1}}
+)
+
+// Steps devolve os passos (dx, dy) permitidos, com dy positivo para cima
+func (m Movement) Steps() [][2]int {
+	switch m {
+	case Orthogonal:
+		return orthogonalSteps
+	case Diagonal:
+		return diagonalSteps
+	case Knight:
+		return knightSteps
+	default:
+		return kingSteps
+	}
+}
+
+// Allows diz se (dx, dy) é um passo permitido
+func (m Movement) Allows(dx, dy int) bool {
+	for _, step := range m.Steps() {
+		if step == [2]int{dx, dy} {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid diz se m é uma das regras conhecidas
+func (m Movement) Valid() bool {
+	return m >= 0 && m < Movements
+}
@@ -0,0 +1,71 @@
+package utils
+
+import "math"
+
+// PIDGains são os ganhos e os limites de um PIDController
+type PIDGains struct {
+	Kp, Ki, Kd  float64
+	IntegralMax float64 // Limite do erro acumulado, contra o windup (0 = sem limite)
+	Filter      float64 // Peso da derivada anterior no filtro passa-baixa, de 0 (sem filtro) até 1
+	OutputMax   float64 // Limite da saída, para os dois lados (0 = sem limite)
+}
+
+// PIDTuning são os ganhos dos inimigos em cada dificuldade (índices 1 a 3).
+// No fácil o inimigo só é puxado pelo erro e anda devagar, então fica para
+// trás; no médio o integral tira o atraso; no difícil ele é o mais rápido e
+// chega a passar um pouco do ponto, com a derivada segurando a volta.
+var PIDTuning = [4]PIDGains{
+	1: {Kp: 0.04, OutputMax: 0.05},
+	2: {Kp: 0.06, Ki: 0.002, Kd: 0.05, IntegralMax: 5, Filter: 0.5, OutputMax: 0.09},
+	3: {Kp: 0.1, Ki: 0.004, Kd: 0.2, IntegralMax: 8, Filter: 0.3, OutputMax: 0.14},
+}
+
+// PIDController é um controlador PID que guarda o próprio estado entre as
+// chamadas de Update: o erro acumulado, o erro anterior e a derivada filtrada
+type PIDController struct {
+	Gains      PIDGains
+	integral   float64
+	prevErr    float64
+	derivative float64
+	started    bool // Se já houve um Update desde o último Reset
+}
+
+// NewPIDController cria um controlador com os ganhos g
+func NewPIDController(g PIDGains) *PIDController {
+	return &PIDController{Gains: g}
+}
+
+// DifficultyPID cria um controlador com os ganhos de PIDTuning para a
+// dificuldade dificulty
+func DifficultyPID(dificulty int) *PIDController {
+	return NewPIDController(PIDTuning[min(max(dificulty, 1), 3)])
+}
+
+// Update avança o controlador em um passo e devolve a correção que leva
+// current em direção a setpoint
+func (c *PIDController) Update(setpoint, current float64) float64 {
+	g := c.Gains
+	err := setpoint - current
+
+	c.integral += err
+	if g.IntegralMax > 0 {
+		c.integral = math.Max(-g.IntegralMax, math.Min(c.integral, g.IntegralMax))
+	}
+
+	// No primeiro passo não há erro anterior, e a derivada seria um pico
+	if c.started {
+		c.derivative = g.Filter*c.derivative + (1-g.Filter)*(err-c.prevErr)
+	}
+	c.prevErr, c.started = err, true
+
+	output := g.Kp*err + g.Ki*c.integral + g.Kd*c.derivative
+	if g.OutputMax > 0 {
+		output = math.Max(-g.OutputMax, math.Min(output, g.OutputMax))
+	}
+	return output
+}
+
+// Reset apaga o estado do controlador, como se ele nunca tivesse sido usado
+func (c *PIDController) Reset() {
+	c.integral, c.prevErr, c.derivative, c.started = 0, 0, 0, false
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+// stepResponse leva uma posição de 0 até um alvo a 5 células, somando a
+// saída do controlador a cada tick. Devolve o primeiro tick a menos de 0,1 do
+// alvo (-1 se não chegou), o quanto passou do alvo e a posição final.
+func stepResponse(c *PIDController, ticks int) (reach int, overshoot, final float64) {
+	const target = 5.0
+	x, reach := 0.0, -1
+	for t := 0; t < ticks; t++ {
+		x += c.Update(target, x)
+		if reach < 0 && x > target-0.1 {
+			reach = t
+		}
+		overshoot = math.Max(overshoot, x-target)
+	}
+	return reach, overshoot, x
+}
+
+// TestPIDStepResponse confere a resposta ao degrau descrita em PIDTuning:
+// quanto maior a dificuldade, mais rápido o inimigo chega, e só o difícil
+// passa um pouco do ponto
+func TestPIDStepResponse(t *testing.T) {
+	tests := []struct {
+		dificulty    int
+		minReach     int
+		maxReach     int
+		maxOvershoot float64
+	}{
+		{1, 100, 200, 0},
+		{2, 50, 100, 0.15},
+		{3, 30, 60, 0.3},
+	}
+	for _, tt := range tests {
+		reach, overshoot, final := stepResponse(DifficultyPID(tt.dificulty), 300)
+		if reach < tt.minReach || reach > tt.maxReach {
+			t.Errorf("dificuldade %d: chegou no tick %d, queria entre %d e %d", tt.dificulty, reach, tt.minReach, tt.maxReach)
+		}
+		if overshoot > tt.maxOvershoot {
+			t.Errorf("dificuldade %d: passou %.3f do alvo, queria até %.3f", tt.dificulty, overshoot, tt.maxOvershoot)
+		}
+		if math.Abs(final-5) > 0.01 {
+			t.Errorf("dificuldade %d: terminou em %.3f, queria 5", tt.dificulty, final)
+		}
+	}
+}
+
+// TestPIDLimits confere os limites de PIDGains: a saída nunca passa de
+// OutputMax e o erro acumulado para em IntegralMax
+func TestPIDLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		gains PIDGains
+		want  float64 // Saída depois de muitos ticks com erro 10 constante
+	}{
+		{"sem limites", PIDGains{Kp: 0.1, Ki: 0.01}, 0.1*10 + 0.01*10*100},
+		{"integral", PIDGains{Kp: 0.1, Ki: 0.01, IntegralMax: 5}, 0.1*10 + 0.01*5},
+		{"saída", PIDGains{Kp: 0.1, Ki: 0.01, OutputMax: 0.5}, 0.5},
+	}
+	for _, tt := range tests {
+		c := NewPIDController(tt.gains)
+		var got float64
+		for i := 0; i < 100; i++ {
+			got = c.Update(10, 0)
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: saída %v, queria %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestPIDDerivativeFirstStep confere que o primeiro Update não tem derivada,
+// mesmo com um erro grande, e que os seguintes têm
+func TestPIDDerivativeFirstStep(t *testing.T) {
+	c := NewPIDController(PIDGains{Kd: 1})
+	if got := c.Update(10, 0); got != 0 {
+		t.Errorf("primeiro Update devolveu %v, queria 0", got)
+	}
+	if got := c.Update(10, 4); got != -4 {
+		t.Errorf("segundo Update devolveu %v, queria -4", got)
+	}
+}
+
+// TestPIDReset confere que, depois de Reset, o controlador responde como um
+// novo, seja qual for o estado que ele tinha
+func TestPIDReset(t *testing.T) {
+	for dificulty := 1; dificulty <= 3; dificulty++ {
+		used := DifficultyPID(dificulty)
+		for i := 0; i < 50; i++ {
+			used.Update(float64(i%7), float64(i%3))
+		}
+		used.Reset()
+		fresh := DifficultyPID(dificulty)
+		for i := 0; i < 50; i++ {
+			setpoint, current := float64(i%5), float64(i%4)/2
+			if got, want := used.Update(setpoint, current), fresh.Update(setpoint, current); got != want {
+				t.Fatalf("dificuldade %d, tick %d: saída %v depois de Reset, queria %v", dificulty, i, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package utils
+
+// Shape descreve o formato de um tabuleiro: um retângulo Width×Height em que
+// algumas células podem ser vazias (Void) e não fazem parte do grid. O nó de
+// uma célula é row*Width + col, com a linha 0 sendo a de entrada.
+type Shape struct {
+	Width    int
+	Height   int
+	Void     map[int]bool // Células fora do tabuleiro (nil = retângulo cheio)
+	Hex      bool         // Grade hexagonal (ver GenerateHexGraph) em vez de quadrada
+	Movement Movement     // Regra de movimento; grades hexagonais só aceitam King
+	Walls    []Wall       // Paredes entre células vizinhas
+}
+
+// SquareShape é o grid L×L clássico
+func SquareShape(L int) Shape {
+	return Shape{Width: L, Height: L}
+}
+
+// Inside diz se a célula (col, row) faz parte do tabuleiro
+func (s Shape) Inside(col, row int) bool {
+	return col >= 0 && col < s.Width && row >= 0 && row < s.Height && !s.Void[row*s.Width+col]
+}
+
+// Nodes devolve as células do tabuleiro, em ordem
+func (s Shape) Nodes() []int {
+	nodes := make([]int, 0, s.Width*s.Height)
+	for node := 0; node < s.Width*s.Height; node++ {
+		if !s.Void[node] {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// Entrances devolve as células por onde o jogador entra no grid: as que se
+// alcançam com um passo a partir da linha logo abaixo do tabuleiro (row -1),
+// onde ele anda livremente. Com o cavalo, isso inclui a linha 1.
+func (s Shape) Entrances() []int {
+	var starts []int
+	for _, node := range s.Nodes() {
+		col, row := node%s.Width, node/s.Width
+		if s.Hex {
+			if row == 0 {
+				starts = append(starts, node)
+			}
+			continue
+		}
+		for _, step := range s.Movement.Steps() {
+			if from := col - step[0]; row-step[1] == -1 && from >= 0 && from < s.Width {
+				starts = append(starts, node)
+				break
+			}
+		}
+	}
+	return starts
+}
+
+// LShape tira o quadrante de cima à direita do retângulo
+func LShape(width, height int) Shape {
+	s := Shape{Width: width, Height: height, Void: make(map[int]bool)}
+	for row := height / 2; row < height; row++ {
+		for col := width / 2; col < width; col++ {
+			s.Void[row*width+col] = true
+		}
+	}
+	return s
+}
+
+// RingShape deixa só uma borda de duas células de largura
+func RingShape(width, height int) Shape {
+	const border = 2
+	s := Shape{Width: width, Height: height, Void: make(map[int]bool)}
+	for row := border; row < height-border; row++ {
+		for col := border; col < width-border; col++ {
+			s.Void[row*width+col] = true
+		}
+	}
+	return s
+}
+
+// IslandsShape abre buracos 2×2 espalhados pelo retângulo, separando o
+// tabuleiro em ilhas ligadas por corredores. A linha de entrada fica inteira.
+func IslandsShape(width, height int) Shape {
+	s := Shape{Width: width, Height: height, Void: make(map[int]bool)}
+	for row := 2; row < height-1; row += 4 {
+		for col := 1; col < width-1; col += 4 {
+			for _, d := range [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				if row+d[1] < height && col+d[0] < width {
+					s.Void[(row+d[1])*width+col+d[0]] = true
+				}
+			}
+		}
+	}
+	return s
+}
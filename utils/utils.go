@@ -3,7 +3,7 @@ package utils
 import (
 	"math"
 	"math/rand"
-	"time"
+	"sort"
 )
 
 // Check if the target node can be reached without visiting any trap nodes
@@ -39,6 +39,210 @@ func dfs(graph map[int][]int, traps map[int]bool, visited map[int]bool, current,
 	return false
 }
 
+// maxRoutes caps the route count so open boards don't overflow
+const maxRoutes = 1 << 20
+
+// bfsRoutes runs a breadth-first search from all the starts at once and
+// counts how many shortest paths reach each safe node
+func bfsRoutes(graph map[int][]int, traps map[int]bool, starts []int) (map[int]int, map[int]int) {
+	dist := make(map[int]int)
+	routes := make(map[int]int)
+	queue := make([]int, 0, len(graph))
+	for _, start := range starts {
+		if traps[start] {
+			continue
+		}
+		if _, seen := dist[start]; !seen {
+			dist[start] = 0
+			routes[start] = 1
+			queue = append(queue, start)
+		}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, neighbor := range graph[current] {
+			if traps[neighbor] {
+				continue
+			}
+			d, seen := dist[neighbor]
+			if !seen {
+				dist[neighbor] = dist[current] + 1
+				routes[neighbor] = routes[current]
+				queue = append(queue, neighbor)
+			} else if d == dist[current]+1 {
+				routes[neighbor] = min(routes[neighbor]+routes[current], maxRoutes)
+			}
+		}
+	}
+	return dist, routes
+}
+
+// DistanceMap returns, for every safe node reachable from any of the starts,
+// the number of steps of the shortest safe path to it
+func DistanceMap(graph map[int][]int, traps map[int]bool, starts []int) map[int]int {
+	dist, _ := bfsRoutes(graph, traps, starts)
+	return dist
+}
+
+// ShortestPath returns one shortest safe path from any of the starts to the
+// target, including both ends, or nil if the target cannot be reached
+func ShortestPath(graph map[int][]int, traps map[int]bool, starts []int, target int) []int {
+	dist := DistanceMap(graph, traps, starts)
+	d, ok := dist[target]
+	if !ok {
+		return nil
+	}
+
+	// Walk back from the target, always to a neighbor one step closer
+	path := make([]int, d+1)
+	path[d] = target
+	for i := d - 1; i >= 0; i-- {
+		for _, neighbor := range graph[path[i+1]] {
+			if nd, seen := dist[neighbor]; seen && nd == i {
+				path[i] = neighbor
+				break
+			}
+		}
+	}
+	return path
+}
+
+// CountShortestPaths returns how many distinct shortest safe paths lead from
+// the starts to the target (capped to avoid overflow on open boards)
+func CountShortestPaths(graph map[int][]int, traps map[int]bool, starts []int, target int) int {
+	_, routes := bfsRoutes(graph, traps, starts)
+	return routes[target]
+}
+
+// AStar returns the shortest safe path from start to target, guided by the
+// heuristic h (an estimate of the remaining steps that must never overestimate),
+// or nil if the target cannot be reached
+func AStar(graph map[int][]int, traps map[int]bool, start, target int, h func(node int) int) []int {
+	if traps[start] || traps[target] {
+		return nil
+	}
+	cost := map[int]int{start: 0}
+	came := make(map[int]int)
+	open := []int{start}
+	closed := make(map[int]bool)
+
+	for len(open) > 0 {
+		// Pick the open node with the lowest cost + heuristic
+		best := 0
+		for i, node := range open {
+			if cost[node]+h(node) < cost[open[best]]+h(open[best]) {
+				best = i
+			}
+		}
+		current := open[best]
+		open = append(open[:best], open[best+1:]...)
+
+		if current == target {
+			path := []int{current}
+			for current != start {
+				current = came[current]
+				path = append([]int{current}, path...)
+			}
+			return path
+		}
+		closed[current] = true
+
+		for _, neighbor := range graph[current] {
+			if traps[neighbor] || closed[neighbor] {
+				continue
+			}
+			c, seen := cost[neighbor]
+			if !seen {
+				open = append(open, neighbor)
+			}
+			if !seen || cost[current]+1 < c {
+				cost[neighbor] = cost[current] + 1
+				came[neighbor] = current
+			}
+		}
+	}
+	return nil
+}
+
+// ArticulationCells returns the safe nodes that lie on every safe path from
+// the starts to the target: trapping any one of them would disconnect the
+// treasure. The starts are joined by a virtual source and a single Tarjan DFS
+// finds the cut vertices that separate that source from the target.
+func ArticulationCells(graph map[int][]int, traps map[int]bool, starts []int, target int) []int {
+	const source = -1
+	disc := make(map[int]int)
+	low := make(map[int]int)
+	finish := make(map[int]int)
+	clock := 0
+
+	isStart := make(map[int]bool, len(starts))
+	for _, start := range starts {
+		isStart[start] = true
+	}
+	neighbors := func(node int) []int {
+		if node == source {
+			return starts
+		}
+		if isStart[node] {
+			return append([]int{source}, graph[node]...)
+		}
+		return graph[node]
+	}
+
+	// cuts[v] holds the children of v whose subtree only connects to the
+	// rest of the graph through v
+	cuts := make(map[int][]int)
+	var visit func(node, parent int)
+	visit = func(node, parent int) {
+		clock++
+		disc[node] = clock
+		low[node] = clock
+		for _, next := range neighbors(node) {
+			if traps[next] || next == parent {
+				continue
+			}
+			if _, seen := disc[next]; seen {
+				low[node] = min(low[node], disc[next])
+				continue
+			}
+			visit(next, node)
+			low[node] = min(low[node], low[next])
+			if low[next] >= disc[node] {
+				cuts[node] = append(cuts[node], next)
+			}
+		}
+		finish[node] = clock
+	}
+	visit(source, source)
+
+	t, ok := disc[target]
+	if !ok {
+		return nil
+	}
+	cells := make([]int, 0)
+	for node, children := range cuts {
+		if node == source || node == target {
+			continue
+		}
+		for _, child := range children {
+			if disc[child] <= t && t <= finish[child] {
+				cells = append(cells, node)
+				break
+			}
+		}
+	}
+	sort.Ints(cells)
+	return cells
+}
+
+// NewRand cria um gerador determinístico a partir de uma seed. Todo sorteio
+// do jogo (tabuleiro, modo dos inimigos, tiros) passa por ele, então a mesma
+// seed reproduz a mesma partida.
+func NewRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
 func contains(slice []int, value int) bool {
 	for _, v := range slice {
 		if v == value {
@@ -49,39 +253,36 @@ func contains(slice []int, value int) bool {
 }
 
 // GenerateGraph creates a grid graph with up to 8 connections per node
+// (king moves)
 func GenerateGraph(L int) map[int][]int {
-	graph := make(map[int][]int)
-
-	// Calculate total number of nodes
-	totalNodes := L * L
-
-	// Helper to get row and column of a node
-	getRowCol := func(node int) (int, int) {
-		return node / L, node % L
-	}
+	return GenerateShapeGraph(SquareShape(L))
+}
 
-	// Helper to check if a node is within bounds
-	isValid := func(row, col int) bool {
-		return row >= 0 && row < L && col >= 0 && col < L
+// GenerateShapeGraph creates the grid graph of a shaped board. Void cells are
+// left out, both as nodes and as neighbors, and walls remove the edges they
+// block.
+func GenerateShapeGraph(s Shape) map[int][]int {
+	if s.Hex {
+		return GenerateHexGraph(s)
 	}
+	graph := make(map[int][]int)
 
-	// Directions for the 8 neighbors
-	directions := [][2]int{
-		{-1, -1}, {-1, 0}, {-1, 1}, // Top-left, Top, Top-right
-		{0, -1},          {0, 1},  // Left,        Right
-		{1, -1}, {1, 0}, {1, 1},   // Bottom-left, Bottom, Bottom-right
-	}
+	// The movement rule decides the neighbors: 8 for the king, 4 for
+	// orthogonal or diagonal moves, 8 jumps for the knight
+	steps := s.Movement.Steps()
+	walls := wallSet(s.Walls)
 
 	// Build the graph
-	for node := 0; node < totalNodes; node++ {
-		row, col := getRowCol(node)
+	for _, node := range s.Nodes() {
+		row, col := node/s.Width, node%s.Width
+		graph[node] = []int{}
 
-		for _, dir := range directions {
-			newRow := row + dir[0]
-			newCol := col + dir[1]
+		for _, step := range steps {
+			newRow := row + step[1]
+			newCol := col + step[0]
 
-			if isValid(newRow, newCol) {
-				neighbor := newRow*L + newCol
+			neighbor := newRow*s.Width + newCol
+			if s.Inside(newCol, newRow) && !s.blocked(walls, node, neighbor) {
 				graph[node] = append(graph[node], neighbor)
 			}
 		}
@@ -90,7 +291,8 @@ func GenerateGraph(L int) map[int][]int {
 	return graph
 }
 
-func GenerateTraps(L int, treasure int, dificulty int) (map[int]bool) {
+// GenerateTraps sorteia as armadilhas usando rng, nunca sobre o tesouro
+func GenerateTraps(rng *rand.Rand, L int, treasure int, dificulty int) (map[int]bool) {
 
 	traps := make(map[int]bool)
 	maxNodes := int(L * L)
@@ -101,16 +303,13 @@ func GenerateTraps(L int, treasure int, dificulty int) (map[int]bool) {
 		maxTraps = int(float64(maxTraps) * 0.6)
 	case 2:
 		maxTraps = int(float64(maxTraps) * 0.8)
-	case 3:
-		maxTraps = maxTraps
 	}
 
-	rand.Seed(time.Now().UnixNano())
 	for i := 0; i < int(maxTraps); i++ {
-		node := rand.Intn(int(maxNodes))
+		node := rng.Intn(int(maxNodes))
 		visited = append(visited, node)
 		for node == treasure || contains(visited, node) {
-			node = rand.Intn(int(maxNodes))
+			node = rng.Intn(int(maxNodes))
 		}
 		traps[node] = true
 	}
@@ -120,15 +319,14 @@ func GenerateTraps(L int, treasure int, dificulty int) (map[int]bool) {
 	return traps
 }
 
-func GenerateTreasure(L int) int {
-	rand.Seed(time.Now().UnixNano())
-	treasure := rand.Intn(L*L)
+func GenerateTreasure(rng *rand.Rand, L int) int {
+	treasure := rng.Intn(L*L)
 	return treasure
 }
 
-func RussianRoulette(dificulty int) bool {
+func RussianRoulette(rng *rand.Rand, dificulty int) bool {
 	grandTotal := []int{1, 2, 3, 4, 5, 6}
-	randomInt  := rand.Intn(len(grandTotal))
+	randomInt  := rng.Intn(len(grandTotal))
 	if dificulty == 3 {
 		return grandTotal[randomInt] != 6
 	}
@@ -141,15 +339,15 @@ func RussianRoulette(dificulty int) bool {
 	return true
 }
 
-func RandomFloat64() float64 {
-	return rand.Float64()
+func RandomFloat64(rng *rand.Rand) float64 {
+	return rng.Float64()
 }
 
-func RandomMoves(currentPos, targetPos float64, gridSize int) float64 {
+func RandomMoves(rng *rand.Rand, currentPos, targetPos float64, gridSize int) float64 {
 	// Se estiver próximo do alvo, escolhe um novo alvo
 	if math.Abs(currentPos - targetPos) < 0.1 {
 		// Retorna um valor entre -1 e 1 para indicar direção do movimento
-		return rand.Float64()*2 - 1
+		return rng.Float64()*2 - 1
 	}
 
 	// Move suavemente em direção ao alvo atual
@@ -167,39 +365,8 @@ func RandomMoves(currentPos, targetPos float64, gridSize int) float64 {
 	return direction
 }
 
-// calculatePID calcula o movimento baseado no controle PID
-func CalculatePID(setpoint float64, currentPos float64, dificulty int, errAcum float64, prevErr float64) float64 {
-	var kp, ki, kd float64
-
-	// Ajusta as constantes do PID baseado na dificuldade
-	switch dificulty {
-	case 1: // Fácil - apenas proporcional
-		kp, ki, kd = 0.3, 0, 0
-	case 2: // Médio - proporcional + integral
-		kp, ki, kd = 0.3, 0.1, 0
-	case 3: // Difícil - PID completo
-		kp, ki, kd = 0.3, 0.1, 0.05
-	}
-
-	err := setpoint - currentPos
-	errAcum += err
-	derivative := err - prevErr
-
-	output := (kp * err) + (ki * errAcum) + (kd * derivative)
-	prevErr = err
-
-	// Limita a saída para movimento suave
-	if output > 1 {
-		output = 1
-	} else if output < -1 {
-		output = -1
-	}
-
-	return output
-}
-
-func CaraOuCoroa() bool {
-	r := rand.Intn(2)
+func CaraOuCoroa(rng *rand.Rand) bool {
+	r := rng.Intn(2)
 	if r == 0 {
 		return true
 	}
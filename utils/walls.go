@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Wall é uma parede entre duas células vizinhas, guardada com o menor nó
+// primeiro. Paredes bloqueiam passos, não células: o grafo do tabuleiro
+// simplesmente perde a aresta.
+type Wall [2]int
+
+// NewWall devolve a parede entre os nós a e b
+func NewWall(a, b int) Wall {
+	if a > b {
+		a, b = b, a
+	}
+	return Wall{a, b}
+}
+
+// wallSet transforma a lista de paredes em um conjunto
+func wallSet(walls []Wall) map[Wall]bool {
+	set := make(map[Wall]bool, len(walls))
+	for _, w := range walls {
+		set[NewWall(w[0], w[1])] = true
+	}
+	return set
+}
+
+// WallSlots devolve, em ordem, todos os pares de células vizinhas que podem
+// ter uma parede entre si: lado a lado ou uma em cima da outra na grade
+// quadrada, e qualquer par de hexágonos vizinhos na hexagonal. A lista sai
+// ordenada como as paredes de GenerateWalls.
+func (s Shape) WallSlots() []Wall {
+	forward := [][2]int{{1, 0}, {0, 1}}
+	var slots []Wall
+	for _, node := range s.Nodes() {
+		col, row := node%s.Width, node/s.Width
+		if s.Hex {
+			q, r := OffsetToAxial(col, row)
+			for _, dir := range [][2]int{HexDirections[0], HexDirections[3], HexDirections[2]} {
+				c, rr := AxialToOffset(q+dir[0], r+dir[1])
+				if s.Inside(c, rr) {
+					slots = append(slots, NewWall(node, rr*s.Width+c))
+				}
+			}
+			continue
+		}
+		for _, dir := range forward {
+			if s.Inside(col+dir[0], row+dir[1]) {
+				slots = append(slots, NewWall(node, (row+dir[1])*s.Width+col+dir[0]))
+			}
+		}
+	}
+	return slots
+}
+
+// blocked diz se o passo de a para b atravessa alguma parede. Na grade
+// quadrada, um passo diagonal passa pelo canto e só é bloqueado se os dois
+// caminhos em L em volta dele estiverem fechados, por parede ou por célula
+// vazia. Saltos do cavalo pulam as paredes.
+func (s Shape) blocked(walls map[Wall]bool, a, b int) bool {
+	if len(walls) == 0 && len(s.Void) == 0 {
+		return false
+	}
+	if walls[NewWall(a, b)] {
+		return true
+	}
+	if s.Hex {
+		return false
+	}
+	ax, ay := a%s.Width, a/s.Width
+	dx, dy := b%s.Width-ax, b/s.Width-ay
+	if abs(dx) != 1 || abs(dy) != 1 {
+		return false
+	}
+	side := ay*s.Width + ax + dx   // Vizinho na horizontal
+	upDown := (ay+dy)*s.Width + ax // Vizinho na vertical
+	open := func(mid int) bool { return !s.Void[mid] && !walls[NewWall(a, mid)] && !walls[NewWall(mid, b)] }
+	return !open(side) && !open(upDown)
+}
+
+// Blocked diz se o passo entre os nós a e b atravessa alguma das paredes
+func (s Shape) Blocked(a, b int) bool {
+	return s.blocked(wallSet(s.Walls), a, b)
+}
+
+// GenerateWalls espalha até count paredes pelo tabuleiro sem fechar o caminho
+// seguro path: os passos do caminho (e, nos diagonais, os dois lados do canto)
+// ficam sempre abertos. As paredes voltam ordenadas.
+func GenerateWalls(rng *rand.Rand, shape Shape, path []int, count int) []Wall {
+	protected := make(map[Wall]bool)
+	for i := 1; i < len(path); i++ {
+		a, b := path[i-1], path[i]
+		protected[NewWall(a, b)] = true
+		if shape.Hex {
+			continue
+		}
+		ax, ay := a%shape.Width, a/shape.Width
+		dx, dy := b%shape.Width-ax, b/shape.Width-ay
+		if abs(dx) == 1 && abs(dy) == 1 {
+			side := ay*shape.Width + ax + dx
+			upDown := (ay+dy)*shape.Width + ax
+			for _, w := range []Wall{NewWall(a, side), NewWall(side, b), NewWall(a, upDown), NewWall(upDown, b)} {
+				protected[w] = true
+			}
+		}
+	}
+
+	slots := shape.WallSlots()
+	var walls []Wall
+	for _, i := range rng.Perm(len(slots)) {
+		if len(walls) >= count {
+			break
+		}
+		if !protected[slots[i]] {
+			walls = append(walls, slots[i])
+		}
+	}
+	sort.Slice(walls, func(i, j int) bool {
+		if walls[i][0] != walls[j][0] {
+			return walls[i][0] < walls[j][0]
+		}
+		return walls[i][1] < walls[j][1]
+	})
+	return walls
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestBlocked confere os passos barrados por paredes num 3×3, com os nós
+//
+//	6 7 8
+//	3 4 5
+//	0 1 2
+func TestBlocked(t *testing.T) {
+	tests := []struct {
+		name  string
+		walls []Wall
+		void  map[int]bool
+		a, b  int
+		want  bool
+	}{
+		{"sem paredes", nil, nil, 0, 4, false},
+		{"parede no passo", []Wall{{0, 1}}, nil, 0, 1, true},
+		{"parede no passo de volta", []Wall{{0, 1}}, nil, 1, 0, true},
+		{"parede ao lado", []Wall{{0, 1}}, nil, 0, 3, false},
+		{"diagonal com um L aberto", []Wall{{0, 1}}, nil, 0, 4, false},
+		{"diagonal com o canto fechado", []Wall{{0, 1}, {0, 3}}, nil, 0, 4, true},
+		{"diagonal com os dois L cortados", []Wall{{0, 1}, {3, 4}}, nil, 0, 4, true},
+		{"cavalo pula paredes", []Wall{{0, 1}, {0, 3}, {1, 4}, {3, 4}}, nil, 0, 7, false},
+		{"diagonal ao lado de um vazio", nil, map[int]bool{3: true}, 0, 4, false},
+		{"diagonal com um vazio e uma parede", []Wall{{1, 4}}, map[int]bool{3: true}, 0, 4, true},
+		{"diagonal entre dois vazios", nil, map[int]bool{1: true, 3: true}, 0, 4, true},
+	}
+	for _, tt := range tests {
+		s := Shape{Width: 3, Height: 3, Void: tt.void, Walls: tt.walls}
+		if got := s.Blocked(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: %d → %d bloqueado = %v, queria %v", tt.name, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestGenerateWalls confere que as paredes sorteadas ficam nos encaixes do
+// formato e nunca fecham o caminho seguro
+func TestGenerateWalls(t *testing.T) {
+	for _, s := range testShapes {
+		slots := s.WallSlots()
+		for seed := int64(1); seed <= 20; seed++ {
+			rng := NewRand(seed)
+			_, _, path, err := GenerateBoard(rng, s, 2, PathOptions{Length: 10, Turns: 3, Diagonals: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			walls := GenerateWalls(rng, s, path, 15)
+			if len(walls) > 15 {
+				t.Errorf("%+v, seed %d: %d paredes, pedi 15", s, seed, len(walls))
+			}
+			for _, w := range walls {
+				if !slices.Contains(slots, w) {
+					t.Errorf("%+v, seed %d: parede %v fora dos encaixes", s, seed, w)
+				}
+			}
+			walled := s
+			walled.Walls = walls
+			for i := 1; i < len(path); i++ {
+				if walled.Blocked(path[i-1], path[i]) {
+					t.Errorf("%+v, seed %d: parede no caminho entre %d e %d", s, seed, path[i-1], path[i])
+				}
+			}
+		}
+	}
+}